@@ -1,21 +1,23 @@
 package adapt
 
 import (
+	"context"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"net/http"
 )
 
 // Http converts generic "dumb" middleware to context-aware, so that context
-// is maintained throgout calling chain and error value is propagated correctly
+// is maintained throgout calling chain and error value is propagated correctly.
+// Noodle context is passed to the middleware as request context, values added
+// by the middleware to the request context are visible downstream.
 func Http(mw func(http.Handler) http.Handler) noodle.Middleware {
 	return func(next noodle.Handler) noodle.Handler {
 		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
 			var err error
 			wrappedNext := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				err = next(c, w, r)
+				err = next(r.Context(), w, r)
 			})
-			mw(wrappedNext).ServeHTTP(w, r)
+			mw(wrappedNext).ServeHTTP(w, r.WithContext(c))
 			return err
 		}
 	}
@@ -1,11 +1,11 @@
 package adapt_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/andviro/noodle"
 	"github.com/andviro/noodle/adapt"
-	"golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"net/http"
 	"net/http/httptest"
@@ -54,3 +54,23 @@ func TestHttpErrorPropagates(t *testing.T) {
 	is.Err(err)
 	is.Equal(err, testError)
 }
+
+func TestHttpRequestContext(t *testing.T) {
+	is := is.New(t)
+	stdMW := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			is.Equal(r.Context().Value("testKey"), "testValue")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "stdKey", "stdValue")))
+		})
+	}
+
+	n := noodle.New(noodleMW, adapt.Http(stdMW)).Then(
+		func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			is.Equal(ctx.Value("testKey"), "testValue")
+			is.Equal(ctx.Value("stdKey"), "stdValue")
+			return nil
+		},
+	)
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	is.NotErr(n(context.TODO(), httptest.NewRecorder(), r))
+}
@@ -1,8 +1,8 @@
 package adapt
 
 import (
+	"context"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"net/http"
 )
 
@@ -13,9 +13,9 @@ func Negroni(mw func(http.ResponseWriter, *http.Request, http.HandlerFunc)) nood
 		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
 			var err error
 			wrappedNext := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				err = next(c, w, r)
+				err = next(r.Context(), w, r)
 			})
-			mw(w, r, wrappedNext)
+			mw(w, r.WithContext(c), wrappedNext)
 			return err
 		}
 	}
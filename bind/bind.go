@@ -1,11 +1,12 @@
 package bind
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/ajg/form"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"io"
+	"mime"
 	"net/http"
 	"reflect"
 )
@@ -32,34 +33,88 @@ func formC(r io.Reader) Decoder {
 	return form.NewDecoder(r)
 }
 
+// Validator is implemented by models that check their own consistency after binding
+type Validator interface {
+	Validate() error
+}
+
+// BadRequest is returned by binding middlewares wrapping the decoding error
+var BadRequest = noodle.NewHTTPError(http.StatusBadRequest, "bad_request", "Malformed request body")
+
+// Invalid is returned by Validate wrapping the validation error
+var Invalid = noodle.NewHTTPError(http.StatusUnprocessableEntity, "invalid", "Request validation failed")
+
+// Metadata keys of binding middleware descriptions
+const (
+	ModelMeta       = "model"       // reflect.Type of the bound model
+	ContentTypeMeta = "contentType" // media type of request body, empty if unknown
+)
+
 // Generic is a middleware factory for request binding.
 // Accepts Constructor and returns binder for model
 func Generic(dc Constructor) func(interface{}) noodle.Middleware {
+	return generic(dc, "bind.Generic", "")
+}
+
+// generic creates binder factory with middlewares described by name and content type
+func generic(dc Constructor, name, contentType string) func(interface{}) noodle.Middleware {
 	return func(model interface{}) noodle.Middleware {
 		typeModel := reflect.TypeOf(model)
 		if typeModel.Kind() == reflect.Ptr {
 			panic("Bind to pointer is not allowed")
 		}
-		return func(next noodle.Handler) noodle.Handler {
+		return noodle.Named(name, func(next noodle.Handler) noodle.Handler {
 			return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
 				res := reflect.New(typeModel).Interface()
 				err := dc(r.Body).Decode(res)
 				if err != nil {
-					return err
+					return BadRequest.Wrap(err)
 				}
 				return next(context.WithValue(c, bindKey, res), w, r)
 			}
-		}
+		}, ModelMeta, typeModel, ContentTypeMeta, contentType)
 	}
 }
 
 // JSON constructs middleware that parses request body according to provided model
 // and injects parsed object into context
-var JSON = Generic(jsonC)
+var JSON = generic(jsonC, "bind.JSON", "application/json")
 
 // Form constructs middleware that parses request form according to provided model
 // and injects parsed object into context
-var Form = Generic(formC)
+var Form = generic(formC, "bind.Form", "application/x-www-form-urlencoded")
+
+// Negotiate selects Constructor based on request Content-Type. Web forms are
+// decoded with Form constructor, JSON is used for everything else.
+func Negotiate(r *http.Request) Constructor {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt == "application/x-www-form-urlencoded" {
+		return formC
+	}
+	return jsonC
+}
+
+// Decode populates target object from request body using negotiated Constructor.
+// Requests without body leave the target intact.
+func Decode(r *http.Request, v interface{}) error {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
+	if err := Negotiate(r)(r.Body).Decode(v); err != nil {
+		return BadRequest.Wrap(err)
+	}
+	return nil
+}
+
+// Validate runs validation on the model if it implements Validator
+func Validate(v interface{}) error {
+	if vv, ok := v.(Validator); ok {
+		if err := vv.Validate(); err != nil {
+			return Invalid.Wrap(err).WithDetails(err.Error())
+		}
+	}
+	return nil
+}
 
 // GetData extracts data parsed from upstream Bind operation
 func GetData(c context.Context) interface{} {
@@ -2,13 +2,15 @@ package bind_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"github.com/ajg/form"
 	"github.com/andviro/noodle"
 	"github.com/andviro/noodle/bind"
-	"golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -51,7 +53,9 @@ func TestBindJSON(t *testing.T) {
 	is.NotErr(n(context.TODO(), httptest.NewRecorder(), r))
 
 	r, _ = http.NewRequest("POST", "http://localhost", emptyBuf)
-	is.Err(n(context.TODO(), httptest.NewRecorder(), r))
+	err := n(context.TODO(), httptest.NewRecorder(), r)
+	is.Err(err)
+	is.Equal(noodle.AsHTTPError(err).Status, http.StatusBadRequest)
 }
 
 func TestBindPanicsOnPointer(t *testing.T) {
@@ -66,3 +70,56 @@ func TestBindPanicsOnPointer(t *testing.T) {
 	}()
 	is.Equal(err.(string), "Bind to pointer is not allowed")
 }
+
+type validated struct {
+	A int `json:"a"`
+}
+
+func (v validated) Validate() error {
+	if v.A < 0 {
+		return errors.New("a must not be negative")
+	}
+	return nil
+}
+
+func TestDecode(t *testing.T) {
+	is := is.New(t)
+	var res TestStruct
+
+	r, _ := http.NewRequest("POST", "http://localhost", bytes.NewBufferString(`{"a": 1, "b": "Ololo"}`))
+	is.NotErr(bind.Decode(r, &res))
+	is.Equal(res, TestStruct{1, "Ololo"})
+
+	r, _ = http.NewRequest("POST", "http://localhost", bytes.NewBufferString("a=2&b=Form"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	is.NotErr(bind.Decode(r, &res))
+	is.Equal(res, TestStruct{2, "Form"})
+
+	r, _ = http.NewRequest("GET", "http://localhost", nil)
+	is.NotErr(bind.Decode(r, &res))
+	is.Equal(res, TestStruct{2, "Form"})
+
+	r, _ = http.NewRequest("POST", "http://localhost", bytes.NewBufferString("{"))
+	is.True(errors.Is(bind.Decode(r, &res), bind.BadRequest))
+}
+
+func TestValidate(t *testing.T) {
+	is := is.New(t)
+	is.NotErr(bind.Validate(TestStruct{}))
+	is.NotErr(bind.Validate(validated{1}))
+	err := bind.Validate(validated{-1})
+	is.True(errors.Is(err, bind.Invalid))
+	is.Equal(noodle.AsHTTPError(err).Details, "a must not be negative")
+}
+
+func TestDescribe(t *testing.T) {
+	is := is.New(t)
+	info := noodle.Describe(bind.JSON(TestStruct{}))
+	is.Equal(info.Name, "bind.JSON")
+	is.Equal(info.Meta[bind.ModelMeta], reflect.TypeOf(TestStruct{}))
+	is.Equal(info.Meta[bind.ContentTypeMeta], "application/json")
+
+	info = noodle.Describe(bind.Form(TestStruct{}))
+	is.Equal(info.Name, "bind.Form")
+	is.Equal(info.Meta[bind.ContentTypeMeta], "application/x-www-form-urlencoded")
+}
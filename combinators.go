@@ -0,0 +1,159 @@
+package noodle
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Predicate decides whether conditional middlewares apply to the request
+type Predicate func(context.Context, *http.Request) bool
+
+// Case pairs Predicate with the middleware chain applied when it holds
+type Case struct {
+	When  Predicate
+	Chain Chain
+}
+
+// When creates Case for Switch
+func When(p Predicate, mws ...Middleware) Case {
+	return Case{p, New(mws...)}
+}
+
+// Otherwise creates Case that always matches. Use it as the last Case of Switch.
+func Otherwise(mws ...Middleware) Case {
+	return When(Always, mws...)
+}
+
+// Switch creates middleware that applies chain of the first Case whose
+// predicate holds for the request. If no Case matches, request is passed to
+// the next handler directly.
+func Switch(cases ...Case) Middleware {
+	return func(next Handler) Handler {
+		branches := make([]Handler, len(cases))
+		for i := range cases {
+			branches[i] = cases[i].Chain.Then(next)
+		}
+		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+			for i := range cases {
+				if cases[i].When(c, r) {
+					return branches[i](c, w, r)
+				}
+			}
+			return next(c, w, r)
+		}
+	}
+}
+
+// If creates middleware that applies its middlewares only when predicate holds
+func If(p Predicate, mws ...Middleware) Middleware {
+	return Switch(When(p, mws...))
+}
+
+// Unless creates middleware that applies its middlewares only when predicate does not hold
+func Unless(p Predicate, mws ...Middleware) Middleware {
+	return If(Not(p), mws...)
+}
+
+// Always is a Predicate that holds for any request
+func Always(context.Context, *http.Request) bool {
+	return true
+}
+
+// Not negates Predicate
+func Not(p Predicate) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		return !p(c, r)
+	}
+}
+
+// And creates Predicate that holds when all of its parameters hold
+func And(ps ...Predicate) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		for _, p := range ps {
+			if !p(c, r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or creates Predicate that holds when any of its parameters holds
+func Or(ps ...Predicate) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		for _, p := range ps {
+			if p(c, r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Method creates Predicate matching request method against the list
+func Method(methods ...string) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		for _, m := range methods {
+			if strings.EqualFold(r.Method, m) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PathPrefix creates Predicate that holds when request path starts with prefix
+func PathPrefix(prefix string) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+}
+
+// PathGlob creates Predicate matching request path against shell pattern.
+// Pattern syntax is that of path.Match, malformed patterns cause panic.
+func PathGlob(pattern string) Predicate {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(err)
+	}
+	return func(c context.Context, r *http.Request) bool {
+		ok, _ := path.Match(pattern, r.URL.Path)
+		return ok
+	}
+}
+
+// Header creates Predicate that holds when request header has the value.
+// Empty value matches any request where the header is present.
+func Header(name, value string) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		vals, ok := r.Header[http.CanonicalHeaderKey(name)]
+		if !ok || value == "" {
+			return ok
+		}
+		for _, v := range vals {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ContentType creates Predicate matching media type of request body against the list.
+// Media type parameters such as charset are ignored.
+func ContentType(types ...string) Predicate {
+	return func(c context.Context, r *http.Request) bool {
+		mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+		for _, t := range types {
+			if strings.EqualFold(mt, t) {
+				return true
+			}
+		}
+		return false
+	}
+}
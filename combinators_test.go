@@ -0,0 +1,87 @@
+package noodle_test
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func runRequest(h noodle.Handler, method, path string, headers ...string) string {
+	r, _ := http.NewRequest(method, "http://localhost"+path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Body.String()
+}
+
+func TestIf(t *testing.T) {
+	is := is.New(t)
+	h := noodle.New(mwFactory("A"), noodle.If(noodle.PathPrefix("/api"), mwFactory("B"), mwFactory("C"))).
+		Then(handlerFactory("H"))
+
+	is.Equal(runRequest(h, "GET", "/api/items"), "A>B>C>H ")
+	is.Equal(runRequest(h, "GET", "/health"), "A>H ")
+}
+
+func TestUnless(t *testing.T) {
+	is := is.New(t)
+	h := noodle.New(noodle.Unless(noodle.PathGlob("/health*"), mwFactory("Auth"))).Then(handlerFactory("H"))
+
+	is.Equal(runRequest(h, "GET", "/healthz"), "H ")
+	is.Equal(runRequest(h, "GET", "/api"), "Auth>H ")
+}
+
+func TestSwitch(t *testing.T) {
+	is := is.New(t)
+	h := noodle.New(noodle.Switch(
+		noodle.When(noodle.Method("POST", "put"), mwFactory("W")),
+		noodle.When(noodle.Header("X-Debug", ""), mwFactory("D")),
+		noodle.Otherwise(mwFactory("R")),
+	)).Then(handlerFactory("H"))
+
+	is.Equal(runRequest(h, "POST", "/"), "W>H ")
+	is.Equal(runRequest(h, "PUT", "/", "X-Debug", "1"), "W>H ")
+	is.Equal(runRequest(h, "GET", "/", "X-Debug", "1"), "D>H ")
+	is.Equal(runRequest(h, "GET", "/"), "R>H ")
+
+	h = noodle.New(noodle.Switch(noodle.When(noodle.Method("POST"), mwFactory("W")))).Then(handlerFactory("H"))
+	is.Equal(runRequest(h, "GET", "/"), "H ")
+}
+
+func TestPredicates(t *testing.T) {
+	is := is.New(t)
+	r, _ := http.NewRequest("POST", "http://localhost/api/v1/items", nil)
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("X-Version", "2")
+	c := context.TODO()
+
+	is.True(noodle.ContentType("text/xml", "application/JSON")(c, r))
+	is.False(noodle.ContentType("text/xml")(c, r))
+	is.True(noodle.Header("X-Version", "2")(c, r))
+	is.False(noodle.Header("X-Version", "3")(c, r))
+	is.False(noodle.Header("X-Missing", "")(c, r))
+	is.True(noodle.PathGlob("/api/*/items")(c, r))
+	is.False(noodle.PathGlob("/api/*")(c, r))
+	is.True(noodle.And(noodle.Method("POST"), noodle.PathPrefix("/api/"))(c, r))
+	is.False(noodle.And(noodle.Method("POST"), noodle.PathPrefix("/admin/"))(c, r))
+	is.True(noodle.Or(noodle.Method("GET"), noodle.PathPrefix("/api/"))(c, r))
+	is.False(noodle.Or()(c, r))
+	is.True(noodle.Not(noodle.Method("GET"))(c, r))
+}
+
+func TestPathGlobPanics(t *testing.T) {
+	is := is.New(t)
+	var err interface{}
+	func() {
+		defer func() {
+			err = recover()
+		}()
+		noodle.PathGlob("/[")
+	}()
+	is.NotNil(err)
+}
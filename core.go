@@ -1,21 +1,18 @@
 package noodle
 
 import (
-	"golang.org/x/net/context"
+	"context"
 	"net/http"
 )
 
-// origin is the root context for all requests
-var origin = context.TODO()
-
 // Handler provides context-aware http.Handler with error return value for
 // enhanced chaining
 type Handler func(context.Context, http.ResponseWriter, *http.Request) error
 
-// ServeHTTP creates empty context and applies Handler to it, satisfying
-// http.Handler interface
+// ServeHTTP applies Handler to the request context, satisfying http.Handler
+// interface. Handler chain is cancelled when client goes away or server shuts down.
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	_ = h(origin, w, r)
+	_ = h(r.Context(), w, r)
 }
 
 // Middleware behaves like standard closure middleware pattern, only with
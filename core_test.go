@@ -1,9 +1,10 @@
 package noodle_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
+	xcontext "golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"net/http"
 	"net/http/httptest"
@@ -82,3 +83,32 @@ func TestThen(t *testing.T) {
 	})
 	is.Equal("Abracadabra", RunHTTP(h))
 }
+
+func TestServeHTTPUsesRequestContext(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "key", "value"))
+	cancel()
+
+	var handled bool
+	h := noodle.New().Then(func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		handled = true
+		is.Equal(c.Value("key"), "value")
+		is.Equal(c.Err(), context.Canceled)
+		return nil
+	})
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+	is.True(handled)
+}
+
+// legacyHandler is declared with x/net/context as older handlers are
+func legacyHandler(c xcontext.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprint(w, "legacy")
+	return nil
+}
+
+func TestNetContextCompat(t *testing.T) {
+	is := is.New(t)
+	h := noodle.New(mwFactory("A")).Then(legacyHandler)
+	is.Equal("A>legacy", RunHTTP(h))
+}
@@ -0,0 +1,141 @@
+package noodle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Info describes middleware for chain introspection
+type Info struct {
+	Name string                 `json:"name"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// described is middleware with attached description. Its apply method value is
+// recognized by Describe, so no registry of described middlewares is needed.
+type described struct {
+	mw   Middleware
+	info Info
+}
+
+type key int
+
+// infoKey holds pointer to Info filled by described middleware on probe
+const infoKey key = iota
+
+// probe is passed by Describe to described middlewares in place of the next
+// handler, so that they return their description instead of wrapping it
+func probe(context.Context, http.ResponseWriter, *http.Request) error {
+	return nil
+}
+
+var (
+	describedPC = reflect.ValueOf((*described)(nil).apply).Pointer()
+	probePC     = reflect.ValueOf(probe).Pointer()
+)
+
+func (d *described) apply(next Handler) Handler {
+	if reflect.ValueOf(next).Pointer() == probePC {
+		return d.report
+	}
+	return d.mw(next)
+}
+
+// report stores description in the Info pointed to by context value
+func (d *described) report(ctx context.Context, _ http.ResponseWriter, _ *http.Request) error {
+	*ctx.Value(infoKey).(*Info) = d.info
+	return nil
+}
+
+// Named attaches name and optional metadata to middleware. Metadata is passed
+// as alternating string keys and arbitrary values.
+func Named(name string, mw Middleware, kv ...interface{}) Middleware {
+	if len(kv)%2 != 0 {
+		panic("noodle: odd number of metadata arguments")
+	}
+	info := Info{Name: name}
+	if len(kv) > 0 {
+		info.Meta = make(map[string]interface{}, len(kv)/2)
+		for i := 0; i < len(kv); i += 2 {
+			info.Meta[kv[i].(string)] = kv[i+1]
+		}
+	}
+	return (&described{mw, info}).apply
+}
+
+// Describe returns Info attached to middleware by Named. Other middlewares are
+// described by the name of their function.
+func Describe(mw Middleware) Info {
+	if mw == nil {
+		return Info{}
+	}
+	if reflect.ValueOf(mw).Pointer() == describedPC {
+		var res Info
+		_ = mw(probe)(context.WithValue(context.Background(), infoKey, &res), nil, nil)
+		return res
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name()
+	return Info{Name: name[strings.LastIndex(name, "/")+1:]}
+}
+
+// Describe lists Info of chain middlewares in order of their application
+func (c Chain) Describe() []Info {
+	res := make([]Info, len(c))
+	for i, mw := range c {
+		res[i] = Describe(mw)
+	}
+	return res
+}
+
+// TraceEvent reports entry to or exit from the handler created by middleware
+type TraceEvent struct {
+	Info    Info
+	Depth   int           // middleware position in chain
+	Exit    bool          // false on entry, true on exit
+	Elapsed time.Duration // time spent in middleware and downstream handlers, set on exit
+	Err     error         // error returned by middleware, set on exit
+	Request *http.Request
+}
+
+// Tracer receives trace events from chain created by Trace
+type Tracer func(context.Context, TraceEvent)
+
+// Trace returns new chain where every middleware reports entry, exit and
+// elapsed time to the tracer. Middleware descriptions are preserved.
+func (c Chain) Trace(t Tracer) Chain {
+	res := make(Chain, len(c))
+	for i, mw := range c {
+		i, mw, info := i, mw, Describe(mw)
+		res[i] = (&described{func(next Handler) Handler {
+			h := mw(next)
+			return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				t(ctx, TraceEvent{Info: info, Depth: i, Request: r})
+				start := time.Now()
+				err := h(ctx, w, r)
+				t(ctx, TraceEvent{Info: info, Depth: i, Exit: true, Elapsed: time.Since(start), Err: err, Request: r})
+				return err
+			}
+		}, info}).apply
+	}
+	return res
+}
+
+// LogTracer is a Tracer that writes events to the standard logger
+func LogTracer(c context.Context, e TraceEvent) {
+	indent := strings.Repeat("  ", e.Depth)
+	if !e.Exit {
+		log.Printf("%s-> %s %s %s", indent, e.Info.Name, e.Request.Method, e.Request.URL.Path)
+		return
+	}
+	var msg string
+	if e.Err != nil {
+		msg = fmt.Sprintf(" error = %s", e.Err)
+	}
+	log.Printf("%s<- %s %s %s [%s]%s", indent, e.Info.Name, e.Request.Method, e.Request.URL.Path, e.Elapsed, msg)
+}
@@ -0,0 +1,87 @@
+package noodle_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/andviro/noodle"
+	"gopkg.in/tylerb/is.v1"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func plainMW(next noodle.Handler) noodle.Handler {
+	return next
+}
+
+func TestDescribe(t *testing.T) {
+	is := is.New(t)
+	auth := noodle.Named("auth", mwFactory("A"), "realm", "API")
+	chain := noodle.New(plainMW, auth, mwFactory("B"), noodle.Named("b", mwFactory("B")))
+
+	infos := chain.Describe()
+	is.Equal(len(infos), 4)
+	is.Equal(infos[0].Name, "noodle_test.plainMW")
+	is.Equal(infos[1].Name, "auth")
+	is.Equal(infos[1].Meta["realm"], "API")
+	is.Equal(infos[2].Name, "noodle_test.mwFactory.func1")
+	is.Equal(infos[3].Name, "b")
+	is.Equal(noodle.Describe(nil).Name, "")
+	// description of wrapped named middleware does not leak to the wrapper
+	is.Equal(noodle.Describe(noodle.If(noodle.Always, auth)).Name, "noodle.Switch.func1")
+
+	h := chain.Then(handlerFactory("H"))
+	is.Equal(RunHTTP(h), "A>B>B>H ")
+}
+
+func TestNamedPanicsOnOddMeta(t *testing.T) {
+	is := is.New(t)
+	var err interface{}
+	func() {
+		defer func() {
+			err = recover()
+		}()
+		noodle.Named("x", plainMW, "key")
+	}()
+	is.NotNil(err)
+}
+
+func TestTrace(t *testing.T) {
+	is := is.New(t)
+	testError := errors.New("test error")
+	var events []noodle.TraceEvent
+	chain := noodle.New(noodle.Named("A", mwFactory("A")), mwFactory("B")).Trace(func(c context.Context, e noodle.TraceEvent) {
+		events = append(events, e)
+	})
+	is.Equal(chain.Describe()[0].Name, "A")
+
+	h := chain.Then(func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		return testError
+	})
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	is.Equal(h(context.TODO(), httptest.NewRecorder(), r), testError)
+	is.Equal(len(events), 4)
+	is.Equal(events[0].Info.Name, "A")
+	is.False(events[0].Exit)
+	is.Equal(events[1].Depth, 1)
+	is.True(events[2].Exit)
+	is.Equal(events[2].Depth, 1)
+	is.Equal(events[3].Err, testError)
+	is.True(events[3].Elapsed >= events[2].Elapsed)
+}
+
+func TestLogTracer(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+	h := noodle.New(noodle.Named("auth", mwFactory("A"))).Trace(noodle.LogTracer).Then(handlerFactory("H"))
+	RunHTTP(h)
+	logString := buf.String()
+	is.True(strings.Contains(logString, "-> auth GET"))
+	is.True(strings.Contains(logString, "<- auth GET"))
+}
@@ -0,0 +1,78 @@
+package noodle
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is an error that knows how to present itself to HTTP client.
+// It carries response status, machine-readable code, human-readable message,
+// optional details and the wrapped cause that is never exposed to the client.
+type HTTPError struct {
+	Status  int         `json:"-" xml:"-"`
+	Code    string      `json:"code" xml:"code"`
+	Message string      `json:"message" xml:"message"`
+	Details interface{} `json:"details,omitempty" xml:"details,omitempty"`
+	Cause   error       `json:"-" xml:"-"`
+}
+
+// NewHTTPError creates HTTPError with provided status, code and message. Empty
+// code and message are derived from the status text
+func NewHTTPError(status int, code, message string) *HTTPError {
+	text := http.StatusText(status)
+	if code == "" {
+		code = strings.ToLower(strings.Replace(text, " ", "_", -1))
+	}
+	if message == "" {
+		message = text
+	}
+	return &HTTPError{Status: status, Code: code, Message: message}
+}
+
+// Error returns error message along with the cause, if any
+func (e *HTTPError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the wrapped cause
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is HTTPError with the same status and code, so
+// that wrapped copies of sentinel errors still match them
+func (e *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	return ok && t.Status == e.Status && t.Code == e.Code
+}
+
+// Wrap returns copy of HTTPError with the cause attached
+func (e *HTTPError) Wrap(cause error) *HTTPError {
+	res := *e
+	res.Cause = cause
+	return &res
+}
+
+// WithDetails returns copy of HTTPError with details attached
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	res := *e
+	res.Details = details
+	return &res
+}
+
+// AsHTTPError walks the error chain and returns the first HTTPError found in it.
+// Any other non-nil error is converted to Internal Server Error wrapping it.
+func AsHTTPError(err error) *HTTPError {
+	if err == nil {
+		return nil
+	}
+	var res *HTTPError
+	if errors.As(err, &res) {
+		return res
+	}
+	return NewHTTPError(http.StatusInternalServerError, "", "").Wrap(err)
+}
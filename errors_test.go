@@ -0,0 +1,50 @@
+package noodle_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+)
+
+func TestNewHTTPError(t *testing.T) {
+	is := is.New(t)
+	e := noodle.NewHTTPError(http.StatusNotFound, "", "")
+	is.Equal(e.Status, 404)
+	is.Equal(e.Code, "not_found")
+	is.Equal(e.Message, "Not Found")
+	is.Equal(e.Error(), "Not Found")
+
+	e = noodle.NewHTTPError(http.StatusConflict, "duplicate", "Already exists")
+	is.Equal(e.Code, "duplicate")
+	is.Equal(e.Message, "Already exists")
+}
+
+func TestHTTPErrorWrap(t *testing.T) {
+	is := is.New(t)
+	cause := errors.New("disk full")
+	sentinel := noodle.NewHTTPError(http.StatusInsufficientStorage, "", "Storage failure")
+	e := sentinel.Wrap(cause).WithDetails([]string{"a"})
+
+	is.Equal(e.Error(), "Storage failure: disk full")
+	is.True(errors.Is(e, cause))
+	is.True(errors.Is(e, sentinel))
+	is.Nil(sentinel.Cause)
+	is.Nil(sentinel.Details)
+}
+
+func TestAsHTTPError(t *testing.T) {
+	is := is.New(t)
+	is.Nil(noodle.AsHTTPError(nil))
+
+	sentinel := noodle.NewHTTPError(http.StatusForbidden, "", "")
+	e := noodle.AsHTTPError(fmt.Errorf("access check: %w", sentinel))
+	is.Equal(e, sentinel)
+
+	cause := errors.New("oops")
+	e = noodle.AsHTTPError(cause)
+	is.Equal(e.Status, http.StatusInternalServerError)
+	is.Equal(e.Cause, cause)
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/render"
+	"net"
+	"net/http"
+)
+
+// errorWriter delays writing of the response status until the body is written,
+// so that error response can replace the status set by a failed handler
+type errorWriter struct {
+	code      int
+	committed bool
+	http.ResponseWriter
+}
+
+func (e *errorWriter) commit() {
+	if e.committed {
+		return
+	}
+	e.committed = true
+	if e.code != 0 {
+		e.ResponseWriter.WriteHeader(e.code)
+	}
+}
+
+func (e *errorWriter) WriteHeader(code int) {
+	if !e.committed && e.code == 0 {
+		e.code = code
+	}
+}
+
+func (e *errorWriter) Write(buf []byte) (int, error) {
+	e.commit()
+	return e.ResponseWriter.Write(buf)
+}
+
+// provide other typical ResponseWriter methods
+func (e *errorWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	e.committed = true
+	return e.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (e *errorWriter) CloseNotify() <-chan bool {
+	return e.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func (e *errorWriter) Flush() {
+	e.commit()
+	e.ResponseWriter.(http.Flusher).Flush()
+}
+
+// ErrorHandler is a middleware that converts errors returned by downstream
+// handlers into HTTP responses. Status and body are taken from the first
+// noodle.HTTPError found in the error chain, other errors produce Internal
+// Server Error. The body is serialized by the rendering middleware reached by
+// request, or written as plain text if there's none. Responses that were
+// already started by the handler are left intact. The error is returned
+// upstream for logging.
+func ErrorHandler(next noodle.Handler) noodle.Handler {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		ew := &errorWriter{ResponseWriter: w}
+		c = render.Observe(c)
+		err := next(c, ew, r)
+		if err == nil || ew.committed {
+			ew.commit()
+			return err
+		}
+		ew.committed = true
+		he := noodle.AsHTTPError(err)
+		if s, contentType, ok := render.Active(c); ok {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(he.Status)
+			_ = s(w, he)
+			return err
+		}
+		w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(he.Status)
+		fmt.Fprintln(w, he.Message)
+		return err
+	}
+}
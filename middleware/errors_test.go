@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/andviro/noodle"
+	mw "github.com/andviro/noodle/middleware"
+	"github.com/andviro/noodle/render"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func failingHandler(err error) noodle.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusTeapot) // must be overridden by error response
+		return err
+	}
+}
+
+func TestErrorHandlerPlainText(t *testing.T) {
+	is := is.New(t)
+	testError := errors.New("test error")
+	n := noodle.New(mw.ErrorHandler).Then(failingHandler(testError))
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	w := httptest.NewRecorder()
+	is.Equal(n(context.TODO(), w, r), testError)
+	is.Equal(w.Code, http.StatusInternalServerError)
+	is.Equal(w.Header().Get("Content-Type"), "text/plain;charset=utf-8")
+	is.Equal(w.Body.String(), "Internal Server Error\n")
+}
+
+func TestErrorHandlerRendersOuter(t *testing.T) {
+	is := is.New(t)
+	n := noodle.New(mw.ErrorHandler, render.JSON, mw.HTTPAuth("test", func(u, p string) bool {
+		return false
+	})).Then(failingHandler(nil))
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	w := httptest.NewRecorder()
+	is.Equal(n(context.TODO(), w, r), mw.UnauthorizedRequest)
+	is.Equal(w.Code, http.StatusUnauthorized)
+	is.Equal(w.Header().Get("Content-Type"), "application/json;charset=utf-8")
+	var res map[string]string
+	is.NotErr(json.Unmarshal(w.Body.Bytes(), &res))
+	is.Equal(res["code"], "unauthorized")
+	is.Equal(res["message"], "Unauthorized request")
+}
+
+func TestErrorHandlerRendersInner(t *testing.T) {
+	is := is.New(t)
+	notFound := noodle.NewHTTPError(http.StatusNotFound, "", "No such item").WithDetails("id")
+	n := noodle.New(render.JSON, mw.ErrorHandler).Then(failingHandler(notFound))
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	w := httptest.NewRecorder()
+	is.Err(n(context.TODO(), w, r))
+	is.Equal(w.Code, http.StatusNotFound)
+	var res map[string]string
+	is.NotErr(json.Unmarshal(w.Body.Bytes(), &res))
+	is.Equal(res["code"], "not_found")
+	is.Equal(res["message"], "No such item")
+	is.Equal(res["details"], "id")
+}
+
+func TestErrorHandlerKeepsStartedResponse(t *testing.T) {
+	is := is.New(t)
+	n := noodle.New(mw.ErrorHandler).Then(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		return errors.New("test error")
+	})
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	w := httptest.NewRecorder()
+	is.Err(n(context.TODO(), w, r))
+	is.Equal(w.Code, http.StatusAccepted)
+	is.Equal(w.Body.String(), "partial")
+}
+
+func TestErrorHandlerPassesStatus(t *testing.T) {
+	is := is.New(t)
+	n := noodle.New(mw.ErrorHandler).Then(failingHandler(nil))
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	w := httptest.NewRecorder()
+	is.NotErr(n(context.TODO(), w, r))
+	is.Equal(w.Code, http.StatusTeapot)
+}
@@ -1,20 +1,20 @@
 package middleware
 
 import (
-	"errors"
+	"context"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"net/http"
 	"net/url"
 )
 
-var UnauthorizedRequest = errors.New("Unauthorized request")
+// UnauthorizedRequest is returned by HTTPAuth when credentials are missing or wrong
+var UnauthorizedRequest error = noodle.NewHTTPError(http.StatusUnauthorized, "unauthorized", "Unauthorized request")
 
 // HTTPAuth is a middleware factory function that accepts the authentication realm
 // and function for username and password verification. Resulting middleware injects
 // username into request context if authentication successful.
 func HTTPAuth(realm string, authFunc func(username, password string) bool) noodle.Middleware {
-	return func(next noodle.Handler) noodle.Handler {
+	return noodle.Named("middleware.HTTPAuth", func(next noodle.Handler) noodle.Handler {
 		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
 			username, password, ok := r.BasicAuth()
 			if !ok || !authFunc(username, password) {
@@ -26,7 +26,7 @@ func HTTPAuth(realm string, authFunc func(username, password string) bool) noodl
 			// Inject user name into request context
 			return next(context.WithValue(c, userKey, username), w, r)
 		}
-	}
+	}, "realm", realm)
 }
 
 // GetUser extract authentication information from context
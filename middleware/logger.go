@@ -2,8 +2,9 @@ package middleware
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"log"
 	"net"
 	"net/http"
@@ -43,7 +44,13 @@ func (l *logWriter) Code() int {
 
 // provide other typical ResponseWriter methods
 func (l *logWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return l.ResponseWriter.(http.Hijacker).Hijack()
+	conn, brw, err := l.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !l.headerWritten {
+		// hijacked connections usually switch protocols, e.g. to WebSocket
+		l.code = http.StatusSwitchingProtocols
+		l.headerWritten = true
+	}
+	return conn, brw, err
 }
 
 func (l *logWriter) CloseNotify() <-chan bool {
@@ -80,11 +87,11 @@ func Logger(next noodle.Handler) noodle.Handler {
 		}
 		var msg string
 		if err != nil {
-			switch t := err.(type) {
-			case RecoverError:
-				msg = t.String()
-			case error:
-				msg = t.Error()
+			var re RecoverError
+			if errors.As(err, &re) {
+				msg = re.String()
+			} else {
+				msg = err.Error()
 			}
 		}
 		log.Printf("%s %s (%d) from %s [%s] error = %s", r.Method, url, lw.Code(), remoteAddr, end.Sub(start), msg)
@@ -2,10 +2,10 @@ package middleware_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"github.com/andviro/noodle"
 	mw "github.com/andviro/noodle/middleware"
-	"golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"log"
 	"net/http"
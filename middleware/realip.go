@@ -1,8 +1,8 @@
 package middleware
 
 import (
+	"context"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"net/http"
 	"strings"
 )
@@ -1,13 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"net/http"
 	"runtime/debug"
 )
 
+// RecoverError holds the value recovered from panic along with the stack trace
 type RecoverError struct {
 	Value      interface{}
 	StackTrace []byte
@@ -22,12 +23,12 @@ func (r RecoverError) String() string {
 }
 
 // Recover is a basic middleware that catches panics and converts them into
-// errors
+// Internal Server Error wrapping RecoverError
 func Recover(next noodle.Handler) noodle.Handler {
 	return func(c context.Context, w http.ResponseWriter, r *http.Request) (err error) {
 		defer func() {
 			if e := recover(); e != nil {
-				err = RecoverError{e, debug.Stack()}
+				err = noodle.NewHTTPError(http.StatusInternalServerError, "", "").Wrap(RecoverError{e, debug.Stack()})
 			}
 		}()
 		err = next(c, w, r)
@@ -1,9 +1,10 @@
 package middleware_test
 
 import (
+	"context"
+	"errors"
 	"github.com/andviro/noodle"
 	mw "github.com/andviro/noodle/middleware"
-	"golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"net/http"
 	"net/http/httptest"
@@ -15,9 +16,13 @@ func TestRecover(t *testing.T) {
 	n := noodle.New(mw.Recover).Then(panickyHandler)
 	r, _ := http.NewRequest("GET", "http://localhost", nil)
 	err := n(context.TODO(), httptest.NewRecorder(), r)
-	is.Equal(err.Error(), "panic: whoopsie!")
-	_, ok := err.(mw.RecoverError)
+	is.Equal(err.Error(), "Internal Server Error: panic: whoopsie!")
+	var re mw.RecoverError
+	is.True(errors.As(err, &re))
+	is.Equal(re.Value, "whoopsie!")
+	he, ok := err.(*noodle.HTTPError)
 	is.True(ok)
+	is.Equal(he.Status, http.StatusInternalServerError)
 }
 
 func panickyHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
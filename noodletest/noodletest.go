@@ -0,0 +1,197 @@
+// Package noodletest provides utilities for testing noodle handlers, chains and
+// routers: a fluent request builder, runners and assertions on results.
+package noodletest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/andviro/noodle"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type key int
+
+const probeKey key = 0
+
+// probe collects context and error seen by the handler wrapped in Capture
+type probe struct {
+	captured bool
+	ctx      context.Context
+	err      error
+}
+
+// Request is a fluent builder of HTTP requests for testing
+type Request struct {
+	method string
+	target string
+	header http.Header
+	body   []byte
+	user   *url.Userinfo
+	values []interface{}
+	err    error
+}
+
+// NewRequest starts building request with method and target URL. Target may
+// be a path or an absolute URL.
+func NewRequest(method, target string) *Request {
+	return &Request{method: method, target: target, header: make(http.Header)}
+}
+
+// Get starts building GET request
+func Get(target string) *Request {
+	return NewRequest("GET", target)
+}
+
+// Post starts building POST request
+func Post(target string) *Request {
+	return NewRequest("POST", target)
+}
+
+// Put starts building PUT request
+func Put(target string) *Request {
+	return NewRequest("PUT", target)
+}
+
+// Patch starts building PATCH request
+func Patch(target string) *Request {
+	return NewRequest("PATCH", target)
+}
+
+// Delete starts building DELETE request
+func Delete(target string) *Request {
+	return NewRequest("DELETE", target)
+}
+
+// Header adds request header
+func (r *Request) Header(key, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+// Body sets raw request body along with its content type
+func (r *Request) Body(contentType string, body []byte) *Request {
+	r.body = body
+	if contentType != "" {
+		r.header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+// JSON sets request body to JSON representation of v
+func (r *Request) JSON(v interface{}) *Request {
+	body, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+	}
+	return r.Body("application/json", body)
+}
+
+// Form sets request body to URL-encoded form
+func (r *Request) Form(values url.Values) *Request {
+	return r.Body("application/x-www-form-urlencoded", []byte(values.Encode()))
+}
+
+// BasicAuth sets request credentials
+func (r *Request) BasicAuth(username, password string) *Request {
+	r.user = url.UserPassword(username, password)
+	return r
+}
+
+// WithValue presets value in request context
+func (r *Request) WithValue(key, value interface{}) *Request {
+	r.values = append(r.values, key, value)
+	return r
+}
+
+// Build creates http.Request. Error is returned if request can not be built.
+func (r *Request) Build() (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	target := r.target
+	if !strings.Contains(target, "://") {
+		target = "http://localhost" + target
+	}
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req, err := http.NewRequest(r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if r.user != nil {
+		password, _ := r.user.Password()
+		req.SetBasicAuth(r.user.Username(), password)
+	}
+	ctx := req.Context()
+	for i := 0; i < len(r.values); i += 2 {
+		ctx = context.WithValue(ctx, r.values[i], r.values[i+1])
+	}
+	return req.WithContext(ctx), nil
+}
+
+// prepare builds request with probe in its context, aborting the test on failure
+func (r *Request) prepare(t testing.TB) (*http.Request, *probe) {
+	t.Helper()
+	req, err := r.Build()
+	if err != nil {
+		t.Fatalf("noodletest: can't build request: %v", err)
+	}
+	p := new(probe)
+	return req.WithContext(context.WithValue(req.Context(), probeKey, p)), p
+}
+
+// Run applies noodle.Handler to the request. Result context is the one seen by
+// handler wrapped in Capture, or request context if there's none.
+func (r *Request) Run(t testing.TB, h noodle.Handler) *Result {
+	t.Helper()
+	req, p := r.prepare(t)
+	res := newResult(t)
+	res.Err = h(req.Context(), res.Recorder, req)
+	res.Context = req.Context()
+	if p.captured {
+		res.Context = p.ctx
+	}
+	return res
+}
+
+// RunChain finalizes chain with handler and applies it to the request. Result
+// context is the one seen by the final handler.
+func (r *Request) RunChain(t testing.TB, c noodle.Chain, final noodle.Handler) *Result {
+	t.Helper()
+	return r.Run(t, c.Then(Capture(final)))
+}
+
+// Serve passes request to http.Handler such as *wok.Wok. Result error and
+// context are available only for handlers wrapped in Capture.
+func (r *Request) Serve(t testing.TB, h http.Handler) *Result {
+	t.Helper()
+	req, p := r.prepare(t)
+	res := newResult(t)
+	h.ServeHTTP(res.Recorder, req)
+	if p.captured {
+		res.Context, res.Err = p.ctx, p.err
+	}
+	return res
+}
+
+// Capture wraps handler so that its context and returned error are recorded
+// into Result of the running request.
+func Capture(h noodle.Handler) noodle.Handler {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		err := h(c, w, r)
+		if p, ok := c.Value(probeKey).(*probe); ok {
+			p.captured, p.ctx, p.err = true, c, err
+		}
+		return err
+	}
+}
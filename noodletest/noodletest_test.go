@@ -0,0 +1,126 @@
+package noodletest_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/bind"
+	mw "github.com/andviro/noodle/middleware"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/render"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type TestStruct struct {
+	A int    `json:"a" form:"a" xml:"a"`
+	B string `json:"b" form:"b" xml:"b"`
+}
+
+// failT records assertion failures instead of failing the test
+type failT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *failT) Helper() {}
+
+func (f *failT) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func echo(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return render.Yield(ctx, http.StatusCreated, bind.GetData(ctx))
+}
+
+func TestRunChain(t *testing.T) {
+	chain := noodle.New(render.JSON, bind.JSON(TestStruct{}))
+	noodletest.Post("/").JSON(TestStruct{1, "JSON"}).RunChain(t, chain, echo).
+		NoError().
+		Status(http.StatusCreated).
+		Header("Content-Type", "application/json;charset=utf-8").
+		JSON(TestStruct{1, "JSON"}).
+		Bound(TestStruct{1, "JSON"}).
+		Rendered(http.StatusCreated, &TestStruct{1, "JSON"})
+
+	noodletest.Post("/").Form(url.Values{"a": {"2"}, "b": {"Form"}}).
+		RunChain(t, noodle.New(render.XML, bind.Form(TestStruct{})), echo).
+		Header("Content-Type", "application/xml;charset=utf-8").
+		XML(TestStruct{2, "Form"})
+}
+
+func TestRun(t *testing.T) {
+	h := noodle.New(mw.HTTPAuth("test", func(u, p string) bool {
+		return p == "secret"
+	})).Then(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprintf(w, "%s %v", mw.GetUser(ctx), ctx.Value("preset"))
+		return nil
+	})
+
+	noodletest.Get("/").Run(t, h).Status(http.StatusUnauthorized).Error(mw.UnauthorizedRequest)
+	noodletest.Get("/").BasicAuth("user", "secret").WithValue("preset", 1).Header("X-Test", "1").Run(t, h).
+		NoError().
+		Status(http.StatusOK).
+		Body("user 1").
+		Value("preset", 1)
+}
+
+func TestServe(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(render.JSON)
+	wk.GET("/:id")(noodletest.Capture(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return render.Yield(ctx, http.StatusOK, map[string]string{"id": wok.Var(ctx, "id")})
+	}))
+
+	res := noodletest.Get("http://example.com/12").Serve(t, wk).
+		NoError().
+		JSON(map[string]string{"id": "12"}).
+		Rendered(http.StatusOK, map[string]string{"id": "12"})
+	is.Equal(wok.Var(res.Context, "id"), "12")
+}
+
+func TestAssertionsFail(t *testing.T) {
+	is := is.New(t)
+	ft := &failT{TB: t}
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprint(w, "{}")
+		return nil
+	}
+
+	noodletest.Get("/").Run(ft, h).
+		Status(http.StatusTeapot).
+		Header("X-Missing", "1").
+		Body("[]").
+		JSON(TestStruct{1, ""}).
+		XML(TestStruct{}).
+		Error(mw.UnauthorizedRequest).
+		Rendered(200, nil).
+		Bound(nil).
+		Value("key", "value")
+	is.Equal(len(ft.failures), 9)
+
+	ft.failures = nil
+	noodletest.Get("/").Serve(ft, noodle.Handler(h)).Value("key", nil).Rendered(200, nil).Bound(nil)
+	is.Equal(ft.failures, []string{
+		"handler context was not captured",
+		"handler context was not captured",
+		"handler context was not captured",
+	})
+}
+
+func TestBuild(t *testing.T) {
+	is := is.New(t)
+	r, err := noodletest.NewRequest("PATCH", "/path?q=1").Body("text/plain", []byte("body")).Build()
+	is.NotErr(err)
+	is.Equal(r.Method, "PATCH")
+	is.Equal(r.URL.String(), "http://localhost/path?q=1")
+	is.Equal(r.Header.Get("Content-Type"), "text/plain")
+
+	_, err = noodletest.Put("/").JSON(func() {}).Build()
+	is.Err(err)
+	_, err = noodletest.Delete("://").Build()
+	is.Err(err)
+}
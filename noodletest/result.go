@@ -0,0 +1,164 @@
+package noodletest
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"github.com/andviro/noodle/bind"
+	"github.com/andviro/noodle/render"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// Result holds outcome of the test request. Assertion methods report
+// failures to the test and return Result for chaining.
+type Result struct {
+	t        testing.TB
+	Recorder *httptest.ResponseRecorder
+	Err      error           // error returned by handler
+	Context  context.Context // context seen by handler
+}
+
+func newResult(t testing.TB) *Result {
+	return &Result{t: t, Recorder: httptest.NewRecorder()}
+}
+
+// Status asserts response status code
+func (res *Result) Status(code int) *Result {
+	res.t.Helper()
+	if res.Recorder.Code != code {
+		res.t.Errorf("expected status %d, got %d", code, res.Recorder.Code)
+	}
+	return res
+}
+
+// Header asserts value of response header
+func (res *Result) Header(key, value string) *Result {
+	res.t.Helper()
+	if got := res.Recorder.Header().Get(key); got != value {
+		res.t.Errorf("expected header %s to be %q, got %q", key, value, got)
+	}
+	return res
+}
+
+// Body asserts response body
+func (res *Result) Body(body string) *Result {
+	res.t.Helper()
+	if got := res.Recorder.Body.String(); got != body {
+		res.t.Errorf("expected body %q, got %q", body, got)
+	}
+	return res
+}
+
+// DecodeJSON decodes response body from JSON into target
+func (res *Result) DecodeJSON(target interface{}) *Result {
+	res.t.Helper()
+	if err := json.Unmarshal(res.Recorder.Body.Bytes(), target); err != nil {
+		res.t.Errorf("can't decode JSON body: %v", err)
+	}
+	return res
+}
+
+// DecodeXML decodes response body from XML into target
+func (res *Result) DecodeXML(target interface{}) *Result {
+	res.t.Helper()
+	if err := xml.Unmarshal(res.Recorder.Body.Bytes(), target); err != nil {
+		res.t.Errorf("can't decode XML body: %v", err)
+	}
+	return res
+}
+
+// JSON asserts that response body decoded from JSON into value of the
+// expected type equals to expected
+func (res *Result) JSON(expected interface{}) *Result {
+	res.t.Helper()
+	return res.decoded(expected, res.DecodeJSON)
+}
+
+// XML asserts that response body decoded from XML into value of the
+// expected type equals to expected
+func (res *Result) XML(expected interface{}) *Result {
+	res.t.Helper()
+	return res.decoded(expected, res.DecodeXML)
+}
+
+func (res *Result) decoded(expected interface{}, decode func(interface{}) *Result) *Result {
+	res.t.Helper()
+	target := reflect.New(reflect.TypeOf(expected))
+	decode(target.Interface())
+	if got := target.Elem().Interface(); !reflect.DeepEqual(got, expected) {
+		res.t.Errorf("expected body %+v, got %+v", expected, got)
+	}
+	return res
+}
+
+// NoError asserts that handler returned no error
+func (res *Result) NoError() *Result {
+	res.t.Helper()
+	if res.Err != nil {
+		res.t.Errorf("expected no error, got %v", res.Err)
+	}
+	return res
+}
+
+// Error asserts that handler returned error matching target by errors.Is
+func (res *Result) Error(target error) *Result {
+	res.t.Helper()
+	if !errors.Is(res.Err, target) {
+		res.t.Errorf("expected error %v, got %v", target, res.Err)
+	}
+	return res
+}
+
+// Value asserts value stored in handler context
+func (res *Result) Value(key, expected interface{}) *Result {
+	res.t.Helper()
+	if res.Context == nil {
+		res.t.Errorf("handler context was not captured")
+		return res
+	}
+	if got := res.Context.Value(key); !reflect.DeepEqual(got, expected) {
+		res.t.Errorf("expected context value %v to be %+v, got %+v", key, expected, got)
+	}
+	return res
+}
+
+// Rendered asserts status code and data passed by handler to render.Yield
+func (res *Result) Rendered(code int, expected interface{}) *Result {
+	res.t.Helper()
+	if res.Context == nil {
+		res.t.Errorf("handler context was not captured")
+		return res
+	}
+	gotCode, got, ok := render.GetResult(res.Context)
+	switch {
+	case !ok:
+		res.t.Errorf("no rendering middleware in handler context")
+	case gotCode != code:
+		res.t.Errorf("expected rendered status %d, got %d", code, gotCode)
+	case !reflect.DeepEqual(got, expected):
+		res.t.Errorf("expected rendered data %+v, got %+v", expected, got)
+	}
+	return res
+}
+
+// Bound asserts model parsed by binding middleware. Expected value is
+// compared to the model pointed to by bind.GetData result.
+func (res *Result) Bound(expected interface{}) *Result {
+	res.t.Helper()
+	if res.Context == nil {
+		res.t.Errorf("handler context was not captured")
+		return res
+	}
+	data := bind.GetData(res.Context)
+	if data == nil {
+		res.t.Errorf("no bound data in handler context")
+		return res
+	}
+	if got := reflect.ValueOf(data).Elem().Interface(); !reflect.DeepEqual(got, expected) {
+		res.t.Errorf("expected bound data %+v, got %+v", expected, got)
+	}
+	return res
+}
@@ -1,22 +1,35 @@
 package render
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"github.com/andviro/noodle"
-	"golang.org/x/net/context"
 	"html/template"
 	"io"
 	"net/http"
 	"sync"
 )
 
-var renderKey int = 0
+type key int
+
+const (
+	renderKey key = iota
+	activeKey
+)
 
 type renderResult struct {
-	mu   sync.RWMutex // guards data
-	code int
-	data interface{}
+	mu          sync.RWMutex // guards data
+	code        int
+	data        interface{}
+	s           SerializerFunc
+	contentType string
+}
+
+// activeRenderer records serializer of the rendering middleware reached by request
+type activeRenderer struct {
+	s           SerializerFunc
+	contentType string
 }
 
 // htmlJSON is a generic template for outputting JSON data inside a PRE tag
@@ -31,7 +44,10 @@ type SerializerFunc func(io.Writer, interface{}) error
 func Generic(s SerializerFunc, contentType string) noodle.Middleware {
 	return func(next noodle.Handler) noodle.Handler {
 		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
-			var res renderResult
+			if a, ok := c.Value(activeKey).(*activeRenderer); ok {
+				a.s, a.contentType = s, contentType
+			}
+			res := renderResult{s: s, contentType: contentType}
 
 			err := next(context.WithValue(c, renderKey, &res), w, r)
 			if err != nil {
@@ -50,25 +66,25 @@ func Generic(s SerializerFunc, contentType string) noodle.Middleware {
 }
 
 // JSON serializes result object into JSON format
-var JSON = Generic(func(w io.Writer, data interface{}) error {
+var JSON = noodle.Named("render.JSON", Generic(func(w io.Writer, data interface{}) error {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(data)
-}, "application/json;charset=utf-8")
+}, "application/json;charset=utf-8"))
 
 // XML serializes result object into "application/xml" content type. Use TextXML for "text/xml" output.
-var XML = Generic(func(w io.Writer, data interface{}) error {
+var XML = noodle.Named("render.XML", Generic(func(w io.Writer, data interface{}) error {
 	return xml.NewEncoder(w).Encode(data)
-}, "application/xml;charset=utf-8")
+}, "application/xml;charset=utf-8"))
 
 // TextXML is the same as XML, but with "text/xml" content type
-var TextXML = Generic(func(w io.Writer, data interface{}) error {
+var TextXML = noodle.Named("render.TextXML", Generic(func(w io.Writer, data interface{}) error {
 	return xml.NewEncoder(w).Encode(data)
-}, "text/xml;charset=utf-8")
+}, "text/xml;charset=utf-8"))
 
 // Template creates middleware that applies pre-compiled template to handler's data object
 func Template(tpl *template.Template) noodle.Middleware {
-	return Generic(tpl.Execute, "text/html;charset=utf-8")
+	return noodle.Named("render.Template", Generic(tpl.Execute, "text/html;charset=utf-8"), "template", tpl.Name())
 }
 
 // ContentType creates renderer middleware that renders response to JSON, XML or HTML template
@@ -87,7 +103,7 @@ func ContentType(tpl *template.Template) noodle.Middleware {
 	} else {
 		htmlRender = Template(tpl)
 	}
-	return func(next noodle.Handler) noodle.Handler {
+	return noodle.Named("render.ContentType", func(next noodle.Handler) noodle.Handler {
 		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
 			switch r.Header.Get("Accept") {
 			case "text/xml":
@@ -100,7 +116,7 @@ func ContentType(tpl *template.Template) noodle.Middleware {
 				return JSON(next)(c, w, r)
 			}
 		}
-	}
+	})
 }
 
 // Yield puts arbitrary data into context for subsequent rendering into response.
@@ -113,3 +129,34 @@ func Yield(c context.Context, code int, data interface{}) error {
 	dest.data = data
 	return nil
 }
+
+// GetResult extracts status code and data passed to Yield by downstream handler.
+// Last return value is false if no rendering middleware is active.
+func GetResult(c context.Context) (int, interface{}, bool) {
+	res, ok := c.Value(renderKey).(*renderResult)
+	if !ok {
+		return 0, nil, false
+	}
+	res.mu.RLock()
+	defer res.mu.RUnlock()
+	return res.code, res.data, true
+}
+
+// Observe prepares context for detection of rendering middleware further down
+// the chain. Use it along with Active in middlewares that precede renderers.
+func Observe(c context.Context) context.Context {
+	return context.WithValue(c, activeKey, &activeRenderer{})
+}
+
+// Active returns serializer and content type of the rendering middleware that
+// handles the context. If context was prepared by Observe, the renderer reached
+// downstream is reported. Last return value is false if no renderer is active.
+func Active(c context.Context) (SerializerFunc, string, bool) {
+	if res, ok := c.Value(renderKey).(*renderResult); ok {
+		return res.s, res.contentType, true
+	}
+	if a, ok := c.Value(activeKey).(*activeRenderer); ok && a.s != nil {
+		return a.s, a.contentType, true
+	}
+	return nil, "", false
+}
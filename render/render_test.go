@@ -1,11 +1,11 @@
 package render_test
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"github.com/andviro/noodle"
 	"github.com/andviro/noodle/render"
-	"golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"html/template"
 	"net/http"
@@ -163,3 +163,14 @@ func TestContentTypeParsesAccept(t *testing.T) {
 		is.Equal(w.Header().Get("Content-Type"), ct.Received)
 	}
 }
+
+func TestDescribe(t *testing.T) {
+	is := is.New(t)
+	tpl := template.Must(template.New("index").Parse(""))
+	infos := noodle.New(render.JSON, render.XML, render.Template(tpl), render.ContentType(nil)).Describe()
+	is.Equal(infos[0].Name, "render.JSON")
+	is.Equal(infos[1].Name, "render.XML")
+	is.Equal(infos[2].Name, "render.Template")
+	is.Equal(infos[2].Meta["template"], "index")
+	is.Equal(infos[3].Name, "render.ContentType")
+}
@@ -0,0 +1,207 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Policy tells broker what to do when client buffer is full
+type Policy int
+
+const (
+	// DropOldest discards the oldest buffered event of the client
+	DropOldest Policy = iota
+	// DropNewest discards the published event for the client
+	DropNewest
+	// Disconnect closes subscription of the client
+	Disconnect
+	// Block makes Publish wait until the client has buffer space
+	Block
+)
+
+// ErrSlowClient is reported by subscriptions closed by Disconnect policy
+var ErrSlowClient = errors.New("sse: client is too slow")
+
+// BrokerOptions configure Broker
+type BrokerOptions struct {
+	Buffer    int           // events buffered per client, 16 if zero
+	Policy    Policy        // policy for clients with full buffer
+	History   int           // published events kept for resuming clients
+	Heartbeat time.Duration // interval of heartbeat comments sent by Serve, none if zero
+}
+
+// Broker fans published events out to subscribers
+type Broker struct {
+	opts    BrokerOptions
+	pubMu   sync.Mutex // serializes publishers, so clients get events in order
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	history []Event
+	lastID  uint64
+}
+
+// NewBroker creates broker
+func NewBroker(opts BrokerOptions) *Broker {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 16
+	}
+	return &Broker{opts: opts, subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives events published by broker
+type Subscription struct {
+	b      *Broker
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// Events returns channel of subscription events
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done returns channel closed when subscription is closed
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns ErrSlowClient if subscription was closed by Disconnect policy
+func (s *Subscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close unsubscribes from broker
+func (s *Subscription) Close() {
+	s.close(nil)
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.b.mu.Lock()
+		delete(s.b.subs, s)
+		s.b.mu.Unlock()
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Publish sends event to all subscribers. Events without id get sequential
+// numeric ids, so clients can resume after reconnect.
+func (b *Broker) Publish(ev Event) {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	b.mu.Lock()
+	b.lastID++
+	if ev.ID == "" {
+		ev.ID = strconv.FormatUint(b.lastID, 10)
+	}
+	if b.opts.History > 0 {
+		b.history = append(b.history, ev)
+		if len(b.history) > b.opts.History {
+			b.history = b.history[len(b.history)-b.opts.History:]
+		}
+	}
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+	for _, s := range subs {
+		b.deliver(s, ev)
+	}
+}
+
+// deliver sends event to subscriber according to policy
+func (b *Broker) deliver(s *Subscription, ev Event) {
+	select {
+	case s.events <- ev:
+		return
+	case <-s.done:
+		return
+	default:
+	}
+	switch b.opts.Policy {
+	case DropOldest:
+		for {
+			select {
+			case s.events <- ev:
+				return
+			case <-s.done:
+				return
+			default:
+			}
+			select {
+			case <-s.events:
+			default:
+			}
+		}
+	case Disconnect:
+		s.close(ErrSlowClient)
+	case Block:
+		select {
+		case s.events <- ev:
+		case <-s.done:
+		}
+	}
+}
+
+// Subscribe adds subscriber. Events from history published after the one
+// with lastEventID are replayed, if lastEventID is found in history.
+func (b *Broker) Subscribe(lastEventID string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var replay []Event
+	if lastEventID != "" {
+		for i, ev := range b.history {
+			if ev.ID == lastEventID {
+				replay = b.history[i+1:]
+				break
+			}
+		}
+	}
+	size := b.opts.Buffer
+	if len(replay) > size {
+		size = len(replay)
+	}
+	s := &Subscription{b: b, events: make(chan Event, size), done: make(chan struct{})}
+	for _, ev := range replay {
+		s.events <- ev
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Serve is a noodle.Handler that streams broker events to the client,
+// resuming from Last-Event-ID, until client disconnects. ErrSlowClient is
+// returned when client is disconnected by policy.
+func (b *Broker) Serve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	s := b.Subscribe(LastEventID(r))
+	defer s.Close()
+	stream, err := Stream(ctx, w)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	if b.opts.Heartbeat > 0 {
+		stream.Heartbeat(b.opts.Heartbeat)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.Done():
+			return s.Err()
+		case ev := <-s.Events():
+			if err := stream.Send(ev); err != nil {
+				return nil
+			}
+		}
+	}
+}
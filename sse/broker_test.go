@@ -0,0 +1,89 @@
+package sse_test
+
+import (
+	"bufio"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/sse"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func received(s *sse.Subscription) []string {
+	var res []string
+	for {
+		select {
+		case ev := <-s.Events():
+			res = append(res, ev.ID+":"+ev.Data)
+		default:
+			return res
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	is := is.New(t)
+	for policy, expected := range map[sse.Policy][]string{
+		sse.DropOldest: {"2:b", "3:c"},
+		sse.DropNewest: {"1:a", "2:b"},
+	} {
+		b := sse.NewBroker(sse.BrokerOptions{Buffer: 2, Policy: policy})
+		s := b.Subscribe("")
+		for _, data := range []string{"a", "b", "c"} {
+			b.Publish(sse.Event{Data: data})
+		}
+		is.Equal(received(s), expected)
+	}
+
+	b := sse.NewBroker(sse.BrokerOptions{Buffer: 1, Policy: sse.Disconnect})
+	s := b.Subscribe("")
+	b.Publish(sse.Event{Data: "a"})
+	b.Publish(sse.Event{Data: "b"})
+	is.Equal(s.Err(), sse.ErrSlowClient)
+
+	b = sse.NewBroker(sse.BrokerOptions{Buffer: 1, Policy: sse.Block})
+	s = b.Subscribe("")
+	done := make(chan struct{})
+	go func() {
+		b.Publish(sse.Event{Data: "a"})
+		b.Publish(sse.Event{Data: "b"})
+		close(done)
+	}()
+	is.Equal((<-s.Events()).Data, "a")
+	<-done
+	is.Equal(received(s), []string{"2:b"})
+	s.Close()
+	b.Publish(sse.Event{Data: "c"})
+	is.NotErr(s.Err())
+}
+
+func TestHistory(t *testing.T) {
+	is := is.New(t)
+	b := sse.NewBroker(sse.BrokerOptions{History: 3})
+	for _, data := range []string{"a", "b", "c", "d"} {
+		b.Publish(sse.Event{Data: data})
+	}
+	is.Equal(received(b.Subscribe("2")), []string{"3:c", "4:d"})
+	is.Equal(len(received(b.Subscribe("1"))), 0)
+	is.Equal(len(received(b.Subscribe(""))), 0)
+}
+
+func TestServe(t *testing.T) {
+	is := is.New(t)
+	b := sse.NewBroker(sse.BrokerOptions{History: 10, Heartbeat: 10 * time.Millisecond})
+	b.Publish(sse.Event{Data: "old"})
+	b.Publish(sse.Event{Data: "missed"})
+	srv := httptest.NewServer(noodle.New().Then(b.Serve))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?lastEventId=1")
+	is.NotErr(err)
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	is.Equal(readEvent(t, br), "id: 2\ndata: missed\n")
+	b.Publish(sse.Event{Event: "update", Data: "new"})
+	is.Equal(readEvent(t, br), "id: 3\nevent: update\ndata: new\n")
+	is.Equal(readEvent(t, br), ": \n")
+}
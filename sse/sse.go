@@ -0,0 +1,150 @@
+// Package sse implements Server-Sent Events streams and a broker that fans
+// events out to subscribed clients.
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a single server-sent event
+type Event struct {
+	ID    string        // event id, remembered by client for resuming
+	Event string        // event type, "message" if empty
+	Data  string        // event data, may contain multiple lines
+	Retry time.Duration // reconnection time suggested to client
+}
+
+// NotSupported is returned when response writer can't be flushed
+var NotSupported = noodle.NewHTTPError(http.StatusInternalServerError, "", "").Wrap(errors.New("sse: streaming is not supported"))
+
+// ErrClosed is returned when writing to closed stream
+var ErrClosed = errors.New("sse: stream closed")
+
+// Writer writes events to the client. Its methods are safe for concurrent
+// use.
+type Writer struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	w      http.ResponseWriter
+	f      http.Flusher
+	closed chan struct{}
+}
+
+// Stream starts event stream by writing response headers. Writes fail with
+// context error after ctx is done, e.g. when client disconnects.
+func Stream(ctx context.Context, w http.ResponseWriter) (*Writer, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, NotSupported
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	return &Writer{ctx: ctx, w: w, f: f, closed: make(chan struct{})}, nil
+}
+
+// LastEventID returns id of the last event received by reconnecting client
+// from Last-Event-ID header or lastEventId query parameter
+func LastEventID(r *http.Request) string {
+	if res := r.Header.Get("Last-Event-ID"); res != "" {
+		return res
+	}
+	return r.URL.Query().Get("lastEventId")
+}
+
+// Send writes event and flushes it to the client
+func (s *Writer) Send(ev Event) error {
+	var b strings.Builder
+	if ev.ID != "" {
+		b.WriteString("id: " + clean(ev.ID) + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + clean(ev.Event) + "\n")
+	}
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(ev.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Comment writes comment line ignored by clients, useful as a heartbeat
+func (s *Writer) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(": " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Heartbeat writes empty comments with the interval in background, keeping
+// the connection alive through proxies, until ctx is done or stream is closed
+func (s *Writer) Heartbeat(interval time.Duration) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-s.closed:
+				return
+			case <-t.C:
+				if err := s.Comment(""); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the stream. It must be called before handler returns if
+// heartbeat is used, since response writer is not valid afterwards.
+func (s *Writer) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+}
+
+func (s *Writer) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return ErrClosed
+	default:
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(s.w, data); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
+
+// clean removes line breaks from single-line fields
+func clean(s string) string {
+	return strings.NewReplacer("\n", "", "\r", "").Replace(s)
+}
@@ -0,0 +1,88 @@
+package sse_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/andviro/noodle"
+	mw "github.com/andviro/noodle/middleware"
+	"github.com/andviro/noodle/sse"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvent reads lines of the next event or comment block
+func readEvent(t *testing.T, br *bufio.Reader) string {
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStream(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(noodle.New(mw.Logger).Then(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		s, err := sse.Stream(ctx, w)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		s.Send(sse.Event{ID: "1", Event: "update", Data: "line 1\nline 2", Retry: 3 * time.Second})
+		s.Send(sse.Event{Data: "last id " + sse.LastEventID(r)})
+		s.Comment("ping")
+		s.Heartbeat(10 * time.Millisecond)
+		<-ctx.Done()
+		return nil
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "41")
+	resp, err := http.DefaultClient.Do(req)
+	is.NotErr(err)
+	defer resp.Body.Close()
+	is.Equal(resp.Header.Get("Content-Type"), "text/event-stream")
+	is.Equal(resp.Header.Get("Cache-Control"), "no-cache")
+	br := bufio.NewReader(resp.Body)
+	is.Equal(readEvent(t, br), "id: 1\nevent: update\nretry: 3000\ndata: line 1\ndata: line 2\n")
+	is.Equal(readEvent(t, br), "data: last id 41\n")
+	is.Equal(readEvent(t, br), ": ping\n")
+	is.Equal(readEvent(t, br), ": \n")
+}
+
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+func TestStreamNotSupported(t *testing.T) {
+	is := is.New(t)
+	_, err := sse.Stream(context.Background(), plainWriter{httptest.NewRecorder()})
+	is.True(errors.Is(err, sse.NotSupported))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := sse.Stream(ctx, httptest.NewRecorder())
+	is.NotErr(err)
+	cancel()
+	is.Equal(s.Send(sse.Event{Data: "x"}), context.Canceled)
+	s.Close()
+	is.Equal(s.Comment("x"), sse.ErrClosed)
+}
+
+func TestLastEventID(t *testing.T) {
+	is := is.New(t)
+	r, _ := http.NewRequest("GET", "http://localhost/?lastEventId=12", nil)
+	is.Equal(sse.LastEventID(r), "12")
+	r.Header.Set("Last-Event-ID", "13")
+	is.Equal(sse.LastEventID(r), "13")
+}
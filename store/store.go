@@ -1,7 +1,9 @@
 package store
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 type KeyError struct {
@@ -12,42 +14,113 @@ func (ke KeyError) Error() string {
 	return "Key `" + ke.Key + "` not found in store"
 }
 
+// EvictFunc is called for entries removed from the store on expiration
+type EvictFunc func(key string, value interface{})
+
 // Store provides simple thread-safe storage for application. It supposed to be
-// injected into context using WithStore function
+// injected into context using WithStore function. Entries may have limited
+// time to live, expired entries are removed lazily on access or by janitor.
 type Store struct {
-	data map[string]interface{}
-	lock sync.RWMutex
+	data    map[string]interface{}
+	expires map[string]time.Time // expiration time of entries with TTL
+	onEvict []EvictFunc
+	lock    sync.RWMutex
+}
+
+// evicted is an expired entry removed from the store
+type evicted struct {
+	key   string
+	value interface{}
 }
 
 // New creates new empty store
 func New() *Store {
-	return &Store{data: make(map[string]interface{})}
+	return &Store{data: make(map[string]interface{}), expires: make(map[string]time.Time)}
 }
 
 // Get reads value from the store, returns value and boolean flag
 func (s *Store) Get(key string) (interface{}, bool) {
 	s.lock.RLock()
-	defer s.lock.RUnlock()
 	data, ok := s.data[key]
+	expired := ok && s.expired(key, time.Now())
+	s.lock.RUnlock()
+	if expired {
+		s.expire(key)
+		return nil, false
+	}
 	return data, ok
 }
 
 // MustGet reads value from the store and panics if there's no such key
 func (s *Store) MustGet(key string) interface{} {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-	data, ok := s.data[key]
+	data, ok := s.Get(key)
 	if !ok {
 		panic(KeyError{key})
 	}
 	return data
 }
 
-// Set saves value to the store
+// Set saves value to the store. Value never expires.
 func (s *Store) Set(key string, value interface{}) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.data[key] = value
+	delete(s.expires, key)
+}
+
+// SetWithTTL saves value to the store for the ttl duration. Non-positive ttl
+// removes the key.
+func (s *Store) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if ttl <= 0 {
+		delete(s.data, key)
+		delete(s.expires, key)
+		return
+	}
+	s.data[key] = value
+	s.expires[key] = time.Now().Add(ttl)
+}
+
+// Expire sets time to live of the existing entry and reports whether the
+// entry was found. Non-positive ttl expires the entry immediately.
+func (s *Store) Expire(key string, ttl time.Duration) bool {
+	s.lock.Lock()
+	now := time.Now()
+	_, ok := s.data[key]
+	if !ok || s.expired(key, now) {
+		s.lock.Unlock()
+		if ok {
+			s.expire(key)
+		}
+		return false
+	}
+	s.expires[key] = now.Add(ttl)
+	s.lock.Unlock()
+	if ttl <= 0 {
+		s.expire(key)
+	}
+	return true
+}
+
+// TTL returns remaining time to live of the entry. Zero is returned for
+// entries that never expire. False is returned if there's no such key.
+func (s *Store) TTL(key string) (time.Duration, bool) {
+	s.lock.RLock()
+	_, ok := s.data[key]
+	at, limited := s.expires[key]
+	s.lock.RUnlock()
+	if !ok {
+		return 0, false
+	}
+	if !limited {
+		return 0, true
+	}
+	if res := time.Until(at); res > 0 {
+		return res, true
+	}
+	s.expire(key)
+	return 0, false
 }
 
 // Delete removes value from store
@@ -55,21 +128,149 @@ func (s *Store) Delete(key string) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	delete(s.data, key)
+	delete(s.expires, key)
+}
+
+// OnEvict registers function that is called for every expired entry removed
+// from the store. Functions are called in registration order outside of the
+// store lock. Entries removed by Delete or overwritten are not reported.
+func (s *Store) OnEvict(f EvictFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onEvict = append(s.onEvict, f)
+}
+
+// Janitor starts background removal of expired entries every interval until
+// ctx is done. Without janitor expired entries are removed when accessed.
+// Panics if interval is not positive.
+func (s *Store) Janitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		panic("store: non-positive janitor interval")
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				s.purge()
+			}
+		}
+	}()
 }
 
 // View executes a function within read-only atomic transaction. Note that function
 // is granted arbitrary access to the underlying map, and writing operations
-// are possible but thread-unsafe.
+// are possible but thread-unsafe. Expired entries are removed beforehand.
 func (s *Store) View(f func(map[string]interface{}) error) error {
 	s.lock.RLock()
-	defer s.lock.RUnlock()
+	if !s.hasExpired(time.Now()) {
+		defer s.lock.RUnlock()
+		return f(s.data)
+	}
+	s.lock.RUnlock()
+	// expired entries are removed under the same lock the function runs with
+	var res []evicted
+	defer func() {
+		s.notify(res)
+	}()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res = s.removeExpired(time.Now())
 	return f(s.data)
 }
 
 // Update executes a function in read-write atomic transaction. Use this method
-// to modify underlying map in thread-safe way.
+// to modify underlying map in thread-safe way. Expired entries are removed
+// beforehand, entries changed by the function keep their time to live.
 func (s *Store) Update(f func(map[string]interface{}) error) error {
+	var res []evicted
+	defer func() {
+		s.notify(res)
+	}()
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	res = s.removeExpired(time.Now())
+	defer s.forgetDeleted()
 	return f(s.data)
 }
+
+// expired reports whether the entry has expired. Must be called with lock held.
+func (s *Store) expired(key string, now time.Time) bool {
+	at, ok := s.expires[key]
+	return ok && !now.Before(at)
+}
+
+// hasExpired reports whether there are expired entries. Must be called with
+// lock held.
+func (s *Store) hasExpired(now time.Time) bool {
+	for _, at := range s.expires {
+		if !now.Before(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeExpired removes expired entries and returns them. Must be called with
+// lock held.
+func (s *Store) removeExpired(now time.Time) []evicted {
+	var res []evicted
+	for key, at := range s.expires {
+		if now.Before(at) {
+			continue
+		}
+		res = append(res, evicted{key, s.data[key]})
+		delete(s.data, key)
+		delete(s.expires, key)
+	}
+	return res
+}
+
+// expire removes the entry if it has expired and notifies eviction callbacks
+func (s *Store) expire(key string) {
+	s.lock.Lock()
+	var res []evicted
+	if s.expired(key, time.Now()) {
+		res = append(res, evicted{key, s.data[key]})
+		delete(s.data, key)
+		delete(s.expires, key)
+	}
+	s.lock.Unlock()
+	s.notify(res)
+}
+
+// purge removes all expired entries and notifies eviction callbacks
+func (s *Store) purge() {
+	s.lock.Lock()
+	res := s.removeExpired(time.Now())
+	s.lock.Unlock()
+	s.notify(res)
+}
+
+// forgetDeleted drops expiration of the entries deleted from the map
+// directly. Must be called with lock held.
+func (s *Store) forgetDeleted() {
+	for key := range s.expires {
+		if _, ok := s.data[key]; !ok {
+			delete(s.expires, key)
+		}
+	}
+}
+
+// notify calls eviction callbacks for the entries
+func (s *Store) notify(entries []evicted) {
+	if len(entries) == 0 {
+		return
+	}
+	s.lock.RLock()
+	callbacks := s.onEvict
+	s.lock.RUnlock()
+	for _, e := range entries {
+		for _, f := range callbacks {
+			f(e.key, e.value)
+		}
+	}
+}
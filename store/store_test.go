@@ -1,6 +1,7 @@
 package store_test
 
 import (
+	"context"
 	"github.com/andviro/noodle/store"
 	"gopkg.in/tylerb/is.v1"
 	"testing"
@@ -73,3 +74,129 @@ func TestViewWaitsForUpdate(t *testing.T) {
 	})
 	is.NotErr(err)
 }
+
+func TestTTL(t *testing.T) {
+	is := is.New(t)
+	s := store.New()
+	var evicted []string
+	s.OnEvict(func(key string, value interface{}) {
+		evicted = append(evicted, key+"="+value.(string))
+	})
+	s.SetWithTTL("token", "abc", 20*time.Millisecond)
+	s.Set("forever", "x")
+	ttl, ok := s.TTL("token")
+	is.True(ok)
+	is.True(ttl > 0 && ttl <= 20*time.Millisecond)
+	ttl, ok = s.TTL("forever")
+	is.True(ok)
+	is.Equal(ttl, time.Duration(0))
+	_, ok = s.TTL("missing")
+	is.False(ok)
+	is.Equal(s.MustGet("token").(string), "abc")
+
+	time.Sleep(30 * time.Millisecond)
+	_, ok = s.Get("token")
+	is.False(ok)
+	_, ok = s.TTL("token")
+	is.False(ok)
+	is.Equal(evicted, []string{"token=abc"})
+
+	// Set clears TTL, Delete is not an eviction
+	s.SetWithTTL("a", "1", 10*time.Millisecond)
+	s.Set("a", "2")
+	s.SetWithTTL("b", "1", 10*time.Millisecond)
+	s.Delete("b")
+	s.SetWithTTL("c", "1", 0)
+	time.Sleep(20 * time.Millisecond)
+	is.Equal(s.MustGet("a").(string), "2")
+	_, ok = s.Get("c")
+	is.False(ok)
+	is.Equal(evicted, []string{"token=abc"})
+}
+
+func TestExpire(t *testing.T) {
+	is := is.New(t)
+	s := store.New()
+	var evicted []string
+	s.OnEvict(func(key string, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	is.False(s.Expire("missing", time.Second))
+	s.Set("a", 1)
+	is.True(s.Expire("a", time.Hour))
+	ttl, _ := s.TTL("a")
+	is.True(ttl > 59*time.Minute)
+	is.True(s.Expire("a", 0))
+	_, ok := s.Get("a")
+	is.False(ok)
+	is.Equal(evicted, []string{"a"})
+}
+
+func TestViewUpdateExpired(t *testing.T) {
+	is := is.New(t)
+	s := store.New()
+	var evicted []string
+	s.OnEvict(func(key string, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	s.SetWithTTL("old", 1, 10*time.Millisecond)
+	s.SetWithTTL("older", 1, 10*time.Millisecond)
+	s.SetWithTTL("fresh", 2, time.Hour)
+	s.Set("key", 3)
+	time.Sleep(20 * time.Millisecond)
+	is.NotErr(s.View(func(m map[string]interface{}) error {
+		is.Equal(m, map[string]interface{}{"fresh": 2, "key": 3})
+		return nil
+	}))
+	is.Equal(len(evicted), 2)
+
+	s.SetWithTTL("old", 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	is.NotErr(s.Update(func(m map[string]interface{}) error {
+		is.Equal(len(m), 2)
+		delete(m, "fresh")
+		m["key"] = 4
+		return nil
+	}))
+	is.Equal(len(evicted), 3)
+	_, ok := s.TTL("fresh")
+	is.False(ok)
+	is.NotErr(s.Update(func(m map[string]interface{}) error {
+		m["fresh"] = 5
+		return nil
+	}))
+	ttl, ok := s.TTL("fresh")
+	is.True(ok)
+	is.Equal(ttl, time.Duration(0))
+}
+
+func TestJanitor(t *testing.T) {
+	is := is.New(t)
+	s := store.New()
+	evicted := make(chan string, 2)
+	s.OnEvict(func(key string, value interface{}) {
+		evicted <- key
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Janitor(ctx, 5*time.Millisecond)
+	s.SetWithTTL("a", 1, 10*time.Millisecond)
+	is.Equal(<-evicted, "a")
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	s.SetWithTTL("b", 1, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	is.Equal(len(evicted), 0)
+	_, ok := s.Get("b")
+	is.False(ok)
+	is.Equal(<-evicted, "b")
+
+	var err interface{}
+	func() {
+		defer func() {
+			err = recover()
+		}()
+		s.Janitor(context.Background(), 0)
+	}()
+	is.Equal(err, "store: non-positive janitor interval")
+}
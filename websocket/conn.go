@@ -0,0 +1,461 @@
+// Package websocket implements WebSocket protocol (RFC 6455) on top of
+// hijacked HTTP connections, so WebSocket endpoints run through noodle
+// middleware chains like any other handler.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is a WebSocket frame opcode
+type MessageType int
+
+// Message types
+const (
+	continuationFrame MessageType = 0
+	TextMessage       MessageType = 1
+	BinaryMessage     MessageType = 2
+	CloseMessage      MessageType = 8
+	PingMessage       MessageType = 9
+	PongMessage       MessageType = 10
+)
+
+// Close codes defined by RFC 6455
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// DefaultReadLimit is the maximum message size used when none is configured
+const DefaultReadLimit = 1 << 20
+
+// maxControlPayload is the maximum payload size of control frames
+const maxControlPayload = 125
+
+// closeTimeout limits writing of close frame
+const closeTimeout = time.Second
+
+var (
+	// ErrClosed is returned when writing to closed connection
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrReadLimit is returned when message exceeds the read limit
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+	// ErrProtocol is wrapped by errors caused by protocol violations of the peer
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrInvalidUTF8 is returned for text messages that are not valid UTF-8
+	ErrInvalidUTF8 = fmt.Errorf("%w: invalid UTF-8 in text message", ErrProtocol)
+)
+
+// CloseError is returned by ReadMessage when the peer closes connection
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Text)
+}
+
+// Conn is a WebSocket connection. ReadMessage must not be called
+// concurrently, while writing methods are safe for concurrent use.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	server      bool // server reads masked frames and writes unmasked ones
+	limit       int64
+	subprotocol string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stop        func() bool
+
+	msgMu   sync.Mutex // serializes fragments of data messages
+	frameMu sync.Mutex // serializes frames
+	closed  bool       // close frame sent, guarded by frameMu
+
+	pingHandler func([]byte) error
+	pongHandler func([]byte) error
+}
+
+// newConn wraps network connection. Connection is closed with CloseGoingAway
+// when ctx is cancelled.
+func newConn(ctx context.Context, conn net.Conn, br *bufio.Reader, server bool, limit int64) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	if limit == 0 {
+		limit = DefaultReadLimit
+	}
+	c := &Conn{conn: conn, br: br, server: server, limit: limit}
+	c.ctx, c.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	c.stop = context.AfterFunc(ctx, func() {
+		c.Close(CloseGoingAway, "")
+	})
+	return c
+}
+
+// Context returns context of the connection. It carries values of the
+// request context and is cancelled when the connection is closed.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Subprotocol returns negotiated subprotocol
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns network address of the peer
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets maximum size of the message read from the peer
+func (c *Conn) SetReadLimit(limit int64) {
+	c.limit = limit
+}
+
+// SetPingHandler sets function called for ping frames instead of replying
+// with pong frame. Returned error is returned by ReadMessage.
+func (c *Conn) SetPingHandler(h func(data []byte) error) {
+	c.pingHandler = h
+}
+
+// SetPongHandler sets function called for pong frames. Returned error is
+// returned by ReadMessage.
+func (c *Conn) SetPongHandler(h func(data []byte) error) {
+	c.pongHandler = h
+}
+
+// SetReadDeadline sets deadline of reading from the underlying connection
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets deadline of writing to the underlying connection
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage reads the next data message, reassembling fragmented ones.
+// Ping frames are replied with pong frames unless ping handler is set. When
+// the peer closes connection, close frame is echoed and CloseError is
+// returned. Protocol violations and messages exceeding read limit close the
+// connection with the appropriate code.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var typ MessageType
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame(int64(len(msg)))
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+		switch op {
+		case PingMessage:
+			if c.pingHandler != nil {
+				err = c.pingHandler(payload)
+			} else {
+				err = c.writeFrame(true, PongMessage, payload)
+				if errors.Is(err, ErrClosed) {
+					err = nil
+				}
+			}
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				if err := c.pongHandler(payload); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.peerClosed(payload)
+		case TextMessage, BinaryMessage:
+			if typ != 0 {
+				return 0, nil, c.fail(fmt.Errorf("%w: unfinished fragmented message", ErrProtocol))
+			}
+			typ = op
+		case continuationFrame:
+			if typ == 0 {
+				return 0, nil, c.fail(fmt.Errorf("%w: unexpected continuation frame", ErrProtocol))
+			}
+		default:
+			return 0, nil, c.fail(fmt.Errorf("%w: unknown opcode %d", ErrProtocol, op))
+		}
+		msg = append(msg, payload...)
+		if !fin {
+			continue
+		}
+		if typ == TextMessage && !utf8.Valid(msg) {
+			return 0, nil, c.fail(ErrInvalidUTF8)
+		}
+		if msg == nil {
+			msg = []byte{}
+		}
+		return typ, msg, nil
+	}
+}
+
+// readFrame reads single frame, read is the size of already read fragments
+func (c *Conn) readFrame(read int64) (fin bool, op MessageType, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin, op = head[0]&0x80 != 0, MessageType(head[0]&0x0f)
+	if head[0]&0x70 != 0 {
+		err = fmt.Errorf("%w: reserved bits set", ErrProtocol)
+		return
+	}
+	masked := head[1]&0x80 != 0
+	if masked != c.server {
+		err = fmt.Errorf("%w: invalid frame masking", ErrProtocol)
+		return
+	}
+	size := int64(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		if size = int64(binary.BigEndian.Uint64(ext[:])); size < 0 {
+			err = fmt.Errorf("%w: invalid frame length", ErrProtocol)
+			return
+		}
+	}
+	if op >= CloseMessage && (!fin || size > maxControlPayload) {
+		err = fmt.Errorf("%w: invalid control frame", ErrProtocol)
+		return
+	}
+	if op < CloseMessage && c.limit > 0 && read+size > c.limit {
+		err = ErrReadLimit
+		return
+	}
+	var key [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, key[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		mask(key, payload)
+	}
+	return
+}
+
+// peerClosed handles close frame received from the peer
+func (c *Conn) peerClosed(payload []byte) error {
+	res := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		return c.fail(fmt.Errorf("%w: invalid close frame", ErrProtocol))
+	case len(payload) >= 2:
+		res.Code = int(binary.BigEndian.Uint16(payload))
+		res.Text = string(payload[2:])
+		if !validCloseCode(res.Code) || !utf8.ValidString(res.Text) {
+			return c.fail(fmt.Errorf("%w: invalid close frame", ErrProtocol))
+		}
+	}
+	code := res.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	c.Close(code, "")
+	return res
+}
+
+// fail closes connection with the code appropriate to the error
+func (c *Conn) fail(err error) error {
+	switch {
+	case errors.Is(err, ErrReadLimit):
+		c.Close(CloseMessageTooBig, "")
+	case errors.Is(err, ErrInvalidUTF8):
+		c.Close(CloseInvalidPayload, "")
+	case errors.Is(err, ErrProtocol):
+		c.Close(CloseProtocolError, "")
+	default:
+		c.shutdown()
+	}
+	return err
+}
+
+// validCloseCode checks whether the code may be sent in close frame
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code < 1000 || code > 1014:
+		return false
+	}
+	return code != 1004 && code != CloseNoStatus && code != CloseAbnormal
+}
+
+// WriteMessage writes data message as single frame
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+	c.msgMu.Lock()
+	defer c.msgMu.Unlock()
+	return c.writeFrame(true, typ, data)
+}
+
+// NextWriter returns writer of fragmented data message. Every Write call
+// sends a fragment, and Close finishes the message. Other messages are
+// blocked until the writer is closed, while control frames may be sent in
+// between.
+func (c *Conn) NextWriter(typ MessageType) (io.WriteCloser, error) {
+	if typ != TextMessage && typ != BinaryMessage {
+		return nil, fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+	c.msgMu.Lock()
+	return &messageWriter{c: c, typ: typ}, nil
+}
+
+type messageWriter struct {
+	c      *Conn
+	typ    MessageType
+	closed bool
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if err := w.c.writeFrame(false, w.typ, p); err != nil {
+		return 0, err
+	}
+	w.typ = continuationFrame
+	return len(p), nil
+}
+
+func (w *messageWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer w.c.msgMu.Unlock()
+	return w.c.writeFrame(true, w.typ, nil)
+}
+
+// Ping sends ping frame
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: control frame payload too large")
+	}
+	return c.writeFrame(true, PingMessage, data)
+}
+
+// Close sends close frame with the code and reason and closes the
+// connection. Subsequent calls do nothing.
+func (c *Conn) Close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(closeTimeout))
+	err := c.writeFrame(true, CloseMessage, payload)
+	c.shutdown()
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// shutdown closes underlying connection and cancels connection context
+func (c *Conn) shutdown() {
+	c.frameMu.Lock()
+	c.closed = true
+	c.frameMu.Unlock()
+	c.stop()
+	c.cancel()
+	c.conn.Close()
+}
+
+// writeFrame writes single frame, masking it on the client side
+func (c *Conn) writeFrame(fin bool, op MessageType, payload []byte) error {
+	c.frameMu.Lock()
+	defer c.frameMu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	if op == CloseMessage {
+		c.closed = true
+	}
+	buf := make([]byte, 0, 14+len(payload))
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	var b1 byte
+	if !c.server {
+		b1 = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		buf = append(buf, b0, b1|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, b0, b1|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, b0, b1|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if c.server {
+		buf = append(buf, payload...)
+	} else {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		buf = append(buf, key[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		mask(key, buf[start:])
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// mask applies masking key to the data in place
+func mask(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i&3]
+	}
+}
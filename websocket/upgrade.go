@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"github.com/andviro/noodle"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// acceptGUID is used for computing Sec-WebSocket-Accept header
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type key int
+
+const upgraderKey key = 0
+
+// BadHandshake is returned when request is not a valid WebSocket handshake
+var BadHandshake = noodle.NewHTTPError(http.StatusBadRequest, "bad_handshake", "Bad WebSocket handshake")
+
+// ForbiddenOrigin is returned when request origin is not allowed
+var ForbiddenOrigin = noodle.NewHTTPError(http.StatusForbidden, "forbidden_origin", "Origin not allowed")
+
+// Upgrader upgrades HTTP requests to WebSocket connections
+type Upgrader struct {
+	// CheckOrigin reports whether request origin is allowed. By default
+	// requests with Origin header must come from the same host.
+	CheckOrigin func(r *http.Request) bool
+	// ReadLimit is the maximum size of messages read from the peer,
+	// DefaultReadLimit if zero, negative means no limit
+	ReadLimit int64
+	// Subprotocols supported by server in order of preference
+	Subprotocols []string
+}
+
+// Handler handles WebSocket connection. Context is cancelled when the
+// connection is closed.
+type Handler func(ctx context.Context, c *Conn) error
+
+// With is a middleware that configures upgrader used by Handle
+func With(u Upgrader) noodle.Middleware {
+	return noodle.Named("websocket.With", func(next noodle.Handler) noodle.Handler {
+		return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+			return next(context.WithValue(c, upgraderKey, &u), w, r)
+		}
+	})
+}
+
+// Handle converts WebSocket handler into noodle.Handler that upgrades the
+// request with upgrader set by With, or default one. Handshake errors are
+// returned before the response is written, so error handling middleware can
+// render them. When handler returns, connection is closed with normal close
+// code, or with internal error code if handler returned error. Errors caused
+// by normal closure of the connection by the peer are not returned.
+func Handle(h Handler) noodle.Handler {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		u, ok := c.Value(upgraderKey).(*Upgrader)
+		if !ok {
+			u = new(Upgrader)
+		}
+		conn, err := u.Upgrade(c, w, r)
+		if err != nil {
+			return err
+		}
+		err = h(conn.Context(), conn)
+		var ce *CloseError
+		switch {
+		case errors.As(err, &ce):
+			if ce.Code == CloseNormal || ce.Code == CloseGoingAway || ce.Code == CloseNoStatus {
+				err = nil
+			}
+			conn.Close(CloseNormal, "")
+		case err != nil:
+			conn.Close(CloseInternalError, "")
+		default:
+			conn.Close(CloseNormal, "")
+		}
+		return err
+	}
+}
+
+// Upgrade performs server side of the handshake and hijacks connection. The
+// connection is closed with CloseGoingAway code when ctx is cancelled.
+func (u *Upgrader) Upgrade(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	switch {
+	case r.Method != "GET",
+		!headerContains(r.Header, "Connection", "upgrade"),
+		!headerContains(r.Header, "Upgrade", "websocket"):
+		return nil, BadHandshake.WithDetails("not a WebSocket upgrade request")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, BadHandshake.WithDetails("unsupported WebSocket version")
+	}
+	challenge := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(challenge); err != nil || len(k) != 16 {
+		return nil, BadHandshake.WithDetails("invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, ForbiddenOrigin
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, noodle.NewHTTPError(http.StatusInternalServerError, "", "").Wrap(errors.New("websocket: response does not support hijacking"))
+	}
+	subprotocol := u.selectSubprotocol(r)
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + acceptKey(challenge) + "\r\n"
+	if subprotocol != "" {
+		resp += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	if _, err := conn.Write([]byte(resp + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res := newConn(ctx, conn, brw.Reader, true, u.ReadLimit)
+	res.subprotocol = subprotocol
+	return res, nil
+}
+
+// selectSubprotocol returns first supported subprotocol requested by client
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	for _, p := range u.Subprotocols {
+		if headerContains(r.Header, "Sec-WebSocket-Protocol", p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// sameOrigin allows requests without Origin header or from the request host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContains checks whether comma-separated header contains token
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptKey computes Sec-WebSocket-Accept value for the key
+func acceptKey(challenge string) string {
+	h := sha1.New()
+	h.Write([]byte(challenge + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,159 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/websocket"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func echo(ctx context.Context, c *websocket.Conn) error {
+	for {
+		typ, msg, err := c.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := c.WriteMessage(typ, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func serve(t *testing.T, h noodle.Handler) (string, chan error) {
+	errs := make(chan error, 1)
+	srv := httptest.NewServer(noodle.Handler(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		err := h(ctx, w, r)
+		errs <- err
+		return err
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), errs
+}
+
+func dial(t *testing.T, url string, header http.Header) *websocket.Conn {
+	c, _, err := websocket.Dial(context.Background(), url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestEcho(t *testing.T) {
+	is := is.New(t)
+	url, errs := serve(t, websocket.Handle(echo))
+	c := dial(t, url, nil)
+
+	is.NotErr(c.WriteMessage(websocket.TextMessage, []byte("hello")))
+	typ, msg, err := c.ReadMessage()
+	is.NotErr(err)
+	is.Equal(typ, websocket.TextMessage)
+	is.Equal(string(msg), "hello")
+
+	big := []byte(strings.Repeat("x", 70000))
+	is.NotErr(c.WriteMessage(websocket.BinaryMessage, big))
+	typ, msg, err = c.ReadMessage()
+	is.NotErr(err)
+	is.Equal(typ, websocket.BinaryMessage)
+	is.Equal(len(msg), len(big))
+
+	w, err := c.NextWriter(websocket.TextMessage)
+	is.NotErr(err)
+	w.Write([]byte("frag"))
+	is.NotErr(c.Ping([]byte("ping")))
+	w.Write([]byte("mented"))
+	is.NotErr(w.Close())
+	var pong string
+	c.SetPongHandler(func(data []byte) error {
+		pong = string(data)
+		return nil
+	})
+	_, msg, err = c.ReadMessage()
+	is.NotErr(err)
+	is.Equal(string(msg), "fragmented")
+	is.Equal(pong, "ping")
+
+	is.NotErr(c.Close(websocket.CloseNormal, "bye"))
+	is.NotErr(<-errs)
+	_, _, err = c.ReadMessage()
+	is.Err(err)
+	is.Equal(c.WriteMessage(websocket.TextMessage, nil), websocket.ErrClosed)
+}
+
+func TestCloseCodes(t *testing.T) {
+	is := is.New(t)
+	url, errs := serve(t, noodle.New(websocket.With(websocket.Upgrader{ReadLimit: 4})).Then(websocket.Handle(echo)))
+
+	c := dial(t, url, nil)
+	is.NotErr(c.WriteMessage(websocket.TextMessage, []byte("too long")))
+	_, _, err := c.ReadMessage()
+	var ce *websocket.CloseError
+	is.True(errors.As(err, &ce))
+	is.Equal(ce.Code, websocket.CloseMessageTooBig)
+	is.True(errors.Is(<-errs, websocket.ErrReadLimit))
+
+	c = dial(t, url, nil)
+	is.NotErr(c.WriteMessage(websocket.TextMessage, []byte{0xff}))
+	_, _, err = c.ReadMessage()
+	is.True(errors.As(err, &ce))
+	is.Equal(ce.Code, websocket.CloseInvalidPayload)
+	is.True(errors.Is(<-errs, websocket.ErrProtocol))
+
+	url, errs = serve(t, websocket.Handle(func(ctx context.Context, c *websocket.Conn) error {
+		return errors.New("failed")
+	}))
+	c = dial(t, url, nil)
+	_, _, err = c.ReadMessage()
+	is.True(errors.As(err, &ce))
+	is.Equal(ce.Code, websocket.CloseInternalError)
+	is.Equal((<-errs).Error(), "failed")
+}
+
+func TestHandshake(t *testing.T) {
+	is := is.New(t)
+	url, errs := serve(t, noodle.New(websocket.With(websocket.Upgrader{Subprotocols: []string{"chat", "v2"}})).Then(websocket.Handle(echo)))
+
+	_, resp, err := websocket.Dial(context.Background(), url, http.Header{"Origin": {"http://evil.com"}})
+	is.Equal(err, websocket.ErrBadHandshake)
+	is.Equal(resp.StatusCode, http.StatusOK) // error is not rendered without ErrorHandler
+	is.True(errors.Is(<-errs, websocket.ForbiddenOrigin))
+
+	resp, err = http.Get("http" + strings.TrimPrefix(url, "ws"))
+	is.NotErr(err)
+	resp.Body.Close()
+	is.True(errors.Is(<-errs, websocket.BadHandshake))
+
+	c := dial(t, url, http.Header{"Sec-Websocket-Protocol": {"v2, chat"}, "Origin": {"http" + strings.TrimPrefix(url, "ws")}})
+	is.Equal(c.Subprotocol(), "chat")
+	c.Close(websocket.CloseNormal, "")
+	<-errs
+}
+
+func TestContextCancel(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	url, errs := serve(t, func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		conn, err := new(websocket.Upgrader).Upgrade(ctx, w, r)
+		if err != nil {
+			return err
+		}
+		close(started)
+		<-conn.Context().Done()
+		return nil
+	})
+	c := dial(t, url, nil)
+	<-started
+	cancel()
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := c.ReadMessage()
+	var ce *websocket.CloseError
+	is.True(errors.As(err, &ce))
+	is.Equal(ce.Code, websocket.CloseGoingAway)
+	is.NotErr(<-errs)
+}
@@ -0,0 +1,42 @@
+package wok
+
+import (
+	"context"
+)
+
+// layeredContext carries deadline and cancellation of the request context,
+// while values missing from the request context are looked up in the root one
+type layeredContext struct {
+	context.Context
+	root context.Context
+}
+
+func (l layeredContext) Value(key interface{}) interface{} {
+	if v := l.Context.Value(key); v != nil {
+		return v
+	}
+	return l.root.Value(key)
+}
+
+// layer combines request and root contexts. Resulting context is cancelled
+// when either of them is done. Returned function releases associated resources.
+func layer(req, root context.Context) (context.Context, context.CancelFunc) {
+	if root == nil {
+		return req, func() {}
+	}
+	if root.Done() == nil {
+		return layeredContext{req, root}, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(req)
+	if root.Err() != nil {
+		cancel(context.Cause(root))
+		return layeredContext{ctx, root}, func() {}
+	}
+	stop := context.AfterFunc(root, func() {
+		cancel(context.Cause(root))
+	})
+	return layeredContext{ctx, root}, func() {
+		stop()
+		cancel(nil)
+	}
+}
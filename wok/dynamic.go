@@ -0,0 +1,363 @@
+package wok
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBatchDone is returned by route closures of Tx called after Batch returns
+var ErrBatchDone = errors.New("batch is already applied")
+
+// registration is a handle registered in Router, kept for rebuilding
+// routers in dynamic mode
+type registration struct {
+	root   *Wok   // group owning Router
+	route  *Route // nil for internal dispatching handles
+	method string
+	path   string
+	handle Handle
+}
+
+// routers maps groups owning Router to routers serving requests
+type routers map[*Wok]*Router
+
+// dynamicState is kept in routeTable for dynamic mode
+type dynamicState struct {
+	entries []registration
+	enabled bool
+	live    atomic.Pointer[routers]
+}
+
+// snapshot is registration state saved by Batch to discard its changes
+type snapshot struct {
+	entries  []registration
+	routes   []*Route
+	names    map[string]*Route
+	pending  []*Route
+	problems []*RouteError
+}
+
+// snapshot saves registration state. Must be called with mutex locked.
+func (t *routeTable) snapshot() snapshot {
+	res := snapshot{
+		entries:  append([]registration(nil), t.dyn.entries...),
+		routes:   append([]*Route(nil), t.routes...),
+		names:    make(map[string]*Route, len(t.names)),
+		pending:  append([]*Route(nil), t.pending...),
+		problems: append([]*RouteError(nil), t.problems...),
+	}
+	for k, v := range t.names {
+		res.names[k] = v
+	}
+	return res
+}
+
+// restore brings back registration state. Must be called with mutex locked.
+func (t *routeTable) restore(s snapshot) {
+	t.dyn.entries, t.routes, t.names = s.entries, s.routes, s.names
+	t.pending, t.problems = s.pending, s.problems
+}
+
+// Dynamic switches router into dynamic mode, where routes may be added and
+// removed while serving requests. Every change rebuilds routing tables off to
+// the side and swaps them atomically, so requests in flight are served by the
+// tables they started with. Settings of the embedded Router, such
+// as RedirectTrailingSlash, are copied into rebuilt tables. Remove and Batch
+// switch router into dynamic mode implicitly. If routing tables can't be
+// rebuilt, router stays in static mode and error is returned and reported by
+// Validate.
+func (wok *Wok) Dynamic() error {
+	t := wok.routes
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dyn.enabled {
+		return nil
+	}
+	t.dyn.enabled = true
+	if err := t.rebuild(); err != nil {
+		t.dyn.enabled = false
+		return t.fail(err)
+	}
+	return nil
+}
+
+// Remove removes route with the method and path relative to the group, as
+// passed to Handle, and reports whether the route was found. Routes added by
+// Mount and Static are matched by method "*" and "GET" respectively. Routes of
+// frozen router can't be removed. If routing tables can't be rebuilt, the
+// route is kept and the error is reported by Validate.
+func (wok *Wok) Remove(method, path string) bool {
+	if wok.routes.isFrozen() || wok.Dynamic() != nil {
+		return false
+	}
+	path, _, _, _ = parseConstraints(UrlJoin(wok.fullPrefix(), path))
+	t := wok.routes
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	found := wok.find(method, path)
+	if found == nil {
+		return false
+	}
+	saved := t.snapshot()
+	t.remove(found)
+	if err := t.rebuild(); err != nil {
+		t.restore(saved)
+		t.fail(err)
+		return false
+	}
+	return true
+}
+
+// find returns route registered with the method and full path by the router
+// owning the group. Must be called with mutex locked.
+func (wok *Wok) find(method, path string) *Route {
+	root := wok.routerRoot()
+	for _, e := range wok.routes.dyn.entries {
+		if e.root == root && e.route != nil && e.route.Method == method && e.route.Path == path {
+			return e.route
+		}
+	}
+	return nil
+}
+
+// Tx stages route changes of Batch. Changes are applied when f passed to
+// Batch returns, so route closures of Tx report nil, while conflicts are
+// reported by Batch.
+type Tx struct {
+	routes *routeTable
+	mu     sync.Mutex
+	ops    []func() error // applied with table mutex locked
+	done   bool
+}
+
+// Handle stages route of the group like Wok.Handle. Route closure must be
+// called before Batch returns, otherwise ErrBatchDone is returned.
+func (tx *Tx) Handle(g *Wok, method, path string, mws ...noodle.Middleware) RouteClosure {
+	route := &Route{Method: method}
+	chain := g.prepare(route, path, mws)
+	_ = tx.stage(g, func() error {
+		g.routes.pending = append(g.routes.pending, route)
+		return nil
+	})
+	return func(h noodle.Handler) error {
+		route.Handler = funcName(h)
+		handle := g.convert(chain.Then(h), route)
+		return tx.stage(g, func() error {
+			g.routes.settle(route)
+			return g.insert(route, []string{method}, []string{route.Path}, handle)
+		})
+	}
+}
+
+// Remove stages removal of the route like Wok.Remove
+func (tx *Tx) Remove(g *Wok, method, path string) {
+	path, _, _, _ = parseConstraints(UrlJoin(g.fullPrefix(), path))
+	_ = tx.stage(g, func() error {
+		if found := g.find(method, path); found != nil {
+			g.routes.remove(found)
+		}
+		return nil
+	})
+}
+
+// stage adds change of the group to be applied by Batch
+func (tx *Tx) stage(g *Wok, op func() error) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return ErrBatchDone
+	}
+	if g.routes != tx.routes {
+		op = func() error {
+			return errors.New("wok: group belongs to another router")
+		}
+	}
+	tx.ops = append(tx.ops, op)
+	return nil
+}
+
+// finish stops staging and returns staged changes
+func (tx *Tx) finish() []func() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.done = true
+	return tx.ops
+}
+
+// Batch applies route changes staged by f in Tx at once, after f returns.
+// Changes made outside of Tx, including concurrent ones, are not part of the
+// batch. If f panics or the resulting routes conflict, none of the staged
+// changes is applied and error is returned. Frozen router can't be changed.
+func (wok *Wok) Batch(f func(tx *Tx)) (err error) {
+	if wok.routes.isFrozen() {
+		return &RouteError{Err: ErrFrozen}
+	}
+	if err := wok.Dynamic(); err != nil {
+		return err
+	}
+	t := wok.routes
+	tx := &Tx{routes: t}
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("wok: %v", p)
+			}
+		}()
+		f(tx)
+	}()
+	ops := tx.finish()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.frozen {
+		return &RouteError{Err: ErrFrozen}
+	}
+	saved := t.snapshot()
+	for _, op := range ops {
+		if err = op(); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = t.rebuild()
+	}
+	if err != nil {
+		t.restore(saved)
+	}
+	return err
+}
+
+// register adds handle for all methods and paths to the router owning the
+// group along with the route description. Nothing is registered if any of the
+// handles conflicts with existing routes.
+func (wok *Wok) register(route *Route, methods, paths []string, h Handle) error {
+	t := wok.routes
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.settle(route)
+	if t.frozen {
+		return &RouteError{Method: methods[0], Path: paths[0], Err: ErrFrozen}
+	}
+	n := len(t.dyn.entries)
+	if err := wok.insert(route, methods, paths, h); err != nil {
+		return t.fail(err)
+	}
+	if t.dyn.enabled {
+		if err := t.rebuild(); err != nil {
+			t.dyn.entries = t.dyn.entries[:n]
+			if route != nil {
+				t.remove(route)
+			}
+			return t.fail(err)
+		}
+	}
+	return nil
+}
+
+// insert registers handles along with the route description. In static mode
+// handles are added to the router owning the group right away, in dynamic
+// mode routers are rebuilt by the caller. Must be called with mutex locked.
+func (wok *Wok) insert(route *Route, methods, paths []string, h Handle) error {
+	root := wok.routerRoot()
+	t := wok.routes
+	if route != nil && route.badPattern != nil {
+		return &RouteError{Method: methods[0], Path: paths[0], Err: route.badPattern}
+	}
+	if route != nil && route.Name != "" {
+		if _, ok := t.names[route.Name]; ok {
+			return &RouteError{Method: methods[0], Path: paths[0], Name: route.Name, Err: ErrDuplicateName}
+		}
+	}
+	var entries []registration
+	for _, method := range methods {
+		for _, path := range paths {
+			entries = append(entries, registration{root, route, method, path, h})
+		}
+	}
+	if !t.dyn.enabled {
+		for _, e := range entries {
+			if err := root.Router.check(e.method, e.path); err != nil {
+				return err
+			}
+		}
+		for _, e := range entries {
+			if err := root.Router.Handle(e.method, e.path, e.handle); err != nil {
+				return err
+			}
+		}
+	}
+	if route != nil {
+		t.add(route)
+	}
+	t.dyn.entries = append(t.dyn.entries, entries...)
+	return nil
+}
+
+// remove deletes route with its handles
+func (t *routeTable) remove(route *Route) {
+	entries := t.dyn.entries[:0:0]
+	for _, e := range t.dyn.entries {
+		if e.route != route {
+			entries = append(entries, e)
+		}
+	}
+	t.dyn.entries = entries
+	routes := t.routes[:0:0]
+	for _, r := range t.routes {
+		if r != route {
+			routes = append(routes, r)
+		}
+	}
+	t.routes = routes
+	if route.Name != "" && t.names[route.Name] == route {
+		delete(t.names, route.Name)
+	}
+}
+
+// rebuild creates new routers for all groups owning one and swaps them in.
+// Conflicting routes are reported as error and leave routers intact.
+func (t *routeTable) rebuild() error {
+	res := make(routers)
+	for _, g := range t.groups {
+		root := g.routerRoot()
+		if res[root] == nil {
+			res[root] = cloneRouter(root.Router)
+		}
+	}
+	for _, e := range t.dyn.entries {
+		if err := res[e.root].Handle(e.method, e.path, e.handle); err != nil {
+			return err
+		}
+	}
+	t.dyn.live.Store(&res)
+	return nil
+}
+
+// cloneRouter creates empty router with settings of r
+func cloneRouter(r *Router) *Router {
+	res := NewRouter()
+	res.RedirectTrailingSlash = r.RedirectTrailingSlash
+	res.RedirectFixedPath = r.RedirectFixedPath
+	res.HandleMethodNotAllowed = r.HandleMethodNotAllowed
+	res.HandleOPTIONS = r.HandleOPTIONS
+	res.GlobalOPTIONS = r.GlobalOPTIONS
+	res.NotFound = r.NotFound
+	res.MethodNotAllowed = r.MethodNotAllowed
+	res.PanicHandler = r.PanicHandler
+	return res
+}
+
+// live returns router serving requests for the group owning one
+func (wok *Wok) live() *Router {
+	if rs := wok.routes.dyn.live.Load(); rs != nil {
+		if res := (*rs)[wok]; res != nil {
+			return res
+		}
+	}
+	return wok.Router
+}
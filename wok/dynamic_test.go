@@ -0,0 +1,163 @@
+package wok_test
+
+import (
+	"errors"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDynamic(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(mwFactory("A"))
+	wk.GET("/static")(handlerFactory("static"))
+	api := wk.Group("/api")
+	api.GET("/users/:id<int>", wok.Name("user"))(handlerFactory("user"))
+	wk.Dynamic()
+
+	api.GET("/items")(handlerFactory("items"))
+	noodletest.Get("/api/items").Serve(t, wk).Body("A>[items]")
+	noodletest.Get("/api/users/1").Serve(t, wk).Body("A>[user]")
+	noodletest.Get("/static").Serve(t, wk).Body("A>[static]")
+
+	is.True(api.Remove("GET", "/users/:id<int>"))
+	is.False(api.Remove("GET", "/users/:id"))
+	is.False(api.Remove("POST", "/items"))
+	noodletest.Get("/api/users/1").Serve(t, wk).Body("A>")
+	_, err := wk.URL("user", "id", "1")
+	is.Err(err)
+	is.Equal(len(wk.Routes()), 2)
+
+	// conflicting route leaves tables intact
+	is.True(errors.Is(api.GET("/items")(handlerFactory("conflict")), wok.ErrDuplicate))
+	is.Equal(len(wk.Routes()), 2)
+	noodletest.Get("/api/items").Serve(t, wk).Body("A>[items]")
+
+	wk.Mount("/mnt", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	wk.Static("/files", fstest.MapFS{"a.txt": {Data: []byte("a")}}, wok.StaticOptions{})
+	noodletest.Get("/files/a.txt").Serve(t, wk).Body("A>a")
+	is.True(wk.Remove("*", "/mnt/*mount"))
+	is.True(wk.Remove("GET", "/files/*filepath"))
+	noodletest.Get("/files/a.txt").Serve(t, wk).Body("A>")
+}
+
+func TestBatch(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/a")(handlerFactory("a"))
+
+	err := wk.Batch(func(tx *wok.Tx) {
+		tx.Remove(wk, "GET", "/a")
+		is.NotErr(tx.Handle(wk, "GET", "/b")(handlerFactory("b")))
+		is.NotErr(tx.Handle(wk, "GET", "/b")(handlerFactory("b")))
+	})
+	is.True(errors.Is(err, wok.ErrDuplicate))
+	noodletest.Get("/a").Serve(t, wk).Body("[a]")
+	noodletest.Get("/b").Serve(t, wk).Status(http.StatusNotFound)
+	is.Equal(len(wk.Routes()), 1)
+
+	var late wok.RouteClosure
+	api := wk.Group("/api")
+	is.NotErr(wk.Batch(func(tx *wok.Tx) {
+		tx.Remove(wk, "GET", "/a")
+		tx.Handle(api, "GET", "/b")(handlerFactory("b"))
+		late = tx.Handle(wk, "GET", "/c")
+	}))
+	noodletest.Get("/a").Serve(t, wk).Status(http.StatusNotFound)
+	noodletest.Get("/api/b").Serve(t, wk).Body("[b]")
+	is.True(errors.Is(late(handlerFactory("c")), wok.ErrBatchDone))
+	is.True(errors.Is(wk.Validate(), wok.ErrNoHandler))
+}
+
+func TestBatchDiscardsProblems(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/a")(handlerFactory("a"))
+	is.Err(wk.Batch(func(tx *wok.Tx) {
+		tx.Handle(wk, "GET", "/pending")
+		tx.Handle(wk, "GET", "/a")(handlerFactory("a"))
+	}))
+	is.Err(wk.Batch(func(tx *wok.Tx) {
+		tx.Handle(wk, "GET", "/pending")
+		panic("boom")
+	}))
+	is.NotErr(wk.Validate())
+}
+
+func TestBatchKeepsUnrelatedChanges(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/a")(handlerFactory("a"))
+	is.Err(wk.Batch(func(tx *wok.Tx) {
+		tx.Handle(wk, "GET", "/a")(handlerFactory("a"))
+		// registered right away, as if by another goroutine
+		is.NotErr(wk.GET("/other")(handlerFactory("other")))
+	}))
+	noodletest.Get("/other").Serve(t, wk).Body("[other]")
+	is.Equal(len(wk.Routes()), 2)
+}
+
+func TestBatchConcurrent(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := "/r" + strconv.Itoa(i)
+			if i%4 == 3 {
+				errs[i] = wk.GET(path)(handlerFactory("r"))
+				return
+			}
+			errs[i] = wk.Batch(func(tx *wok.Tx) {
+				tx.Handle(wk, "GET", path)(handlerFactory("r"))
+				if i%2 == 1 {
+					panic("boom")
+				}
+			})
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		is.Equal(err != nil, i%4 == 1)
+	}
+	is.Equal(len(wk.Routes()), 6)
+	noodletest.Get("/r0").Serve(t, wk).Body("[r]")
+	noodletest.Get("/r1").Serve(t, wk).Status(http.StatusNotFound)
+	noodletest.Get("/r3").Serve(t, wk).Body("[r]")
+}
+
+func TestDynamicConcurrent(t *testing.T) {
+	wk := wok.New()
+	wk.GET("/")(handlerFactory("index"))
+	wk.Dynamic()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("GET", "http://localhost/", nil)
+				wk.ServeHTTP(w, r)
+				if w.Body.String() != "[index]" {
+					t.Errorf("unexpected body %q", w.Body.String())
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		path := "/r" + strconv.Itoa(i)
+		wk.GET(path)(handlerFactory("r"))
+		wk.Remove("GET", path)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,211 @@
+package wok
+
+import (
+	"bufio"
+	"context"
+	"github.com/andviro/noodle"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound is returned by default NotFound handler
+var ErrNotFound = noodle.NewHTTPError(http.StatusNotFound, "", "")
+
+// ErrMethodNotAllowed is returned by default MethodNotAllowed handler
+var ErrMethodNotAllowed = noodle.NewHTTPError(http.StatusMethodNotAllowed, "", "")
+
+type fallbackKind int
+
+const (
+	notFound fallbackKind = iota
+	methodNotAllowed
+	options
+)
+
+// fallbackWriter tracks whether response was started by fallback handler chain
+type fallbackWriter struct {
+	written bool
+	http.ResponseWriter
+}
+
+func (f *fallbackWriter) WriteHeader(code int) {
+	f.written = true
+	f.ResponseWriter.WriteHeader(code)
+}
+
+func (f *fallbackWriter) Write(buf []byte) (int, error) {
+	f.written = true
+	return f.ResponseWriter.Write(buf)
+}
+
+// provide other typical ResponseWriter methods
+func (f *fallbackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	f.written = true
+	return f.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (f *fallbackWriter) Flush() {
+	f.written = true
+	f.ResponseWriter.(http.Flusher).Flush()
+}
+
+// NotFound sets handler for requests that match no route. The handler runs
+// through middleware chain of the deepest group whose prefix matches request
+// path. Group handler takes precedence over the one set on its parent. By
+// default ErrNotFound is returned.
+func (wok *Wok) NotFound(h noodle.Handler) {
+	wok.fallbacks[notFound] = h
+	wok.routes.fallbackGen.Add(1)
+}
+
+// MethodNotAllowed sets handler for requests whose path matches routes
+// registered for other methods. Allow header is set before the handler is
+// called. The handler runs through middleware chain like NotFound. By default
+// ErrMethodNotAllowed is returned.
+func (wok *Wok) MethodNotAllowed(h noodle.Handler) {
+	wok.fallbacks[methodNotAllowed] = h
+	wok.routes.fallbackGen.Add(1)
+}
+
+// Options sets handler for automatic OPTIONS responses on paths that have no
+// explicit OPTIONS route. Allow header is set before the handler is called, so
+// CORS middlewares in the chain can use it. The handler runs through
+// middleware chain like NotFound. By default empty response is written.
+func (wok *Wok) Options(h noodle.Handler) {
+	wok.fallbacks[options] = h
+	wok.routes.fallbackGen.Add(1)
+}
+
+// Allow returns comma-separated list of methods allowed for the path of
+// request handled by MethodNotAllowed or Options handler
+func Allow(c context.Context) string {
+	res, _ := c.Value(allowKey).(string)
+	return res
+}
+
+func defaultNotFound(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	return ErrNotFound
+}
+
+func defaultMethodNotAllowed(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	return ErrMethodNotAllowed
+}
+
+func defaultOptions(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+var defaultFallbacks = [...]noodle.Handler{defaultNotFound, defaultMethodNotAllowed, defaultOptions}
+
+// fallback creates http.Handler for Router that dispatches request to the
+// fallback handler of the deepest matching group. Errors not handled by the
+// chain are written as plain text.
+func (wok *Wok) fallback(kind fallbackKind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := wok.routes.group(wok, r.URL.Path)
+		ctx, cancel := layer(r.Context(), g.context())
+		defer cancel()
+		ctx = context.WithValue(ctx, paramKey, Params(nil))
+		if allow := w.Header().Get("Allow"); allow != "" {
+			ctx = context.WithValue(ctx, allowKey, allow)
+		}
+		_ = g.fallbackHandler(kind)(ctx, w, r)
+	})
+}
+
+// cachedFallback is fallback handler built for the generation of fallback
+// settings
+type cachedFallback struct {
+	gen uint64
+	h   noodle.Handler
+}
+
+// fallbackHandler returns fallback handler of the group wrapped in its
+// middleware chain and tracer. The handler is built once and rebuilt only
+// after fallback handlers or tracers change.
+func (wok *Wok) fallbackHandler(kind fallbackKind) noodle.Handler {
+	gen := wok.routes.fallbackGen.Load()
+	if c := wok.fallbackCache[kind].Load(); c != nil && c.gen == gen {
+		return c.h
+	}
+	h := defaultFallbacks[kind]
+	for router := wok; router != nil; router = router.parent {
+		if router.fallbacks[kind] != nil {
+			h = router.fallbacks[kind]
+			break
+		}
+	}
+	chain := wok.fullChain()
+	if t := wok.activeTracer(); t != nil {
+		chain = chain.Trace(t)
+	}
+	h = writeErrors(chain.Then(h))
+	wok.fallbackCache[kind].Store(&cachedFallback{gen, h})
+	return h
+}
+
+// writeErrors wraps handler so that errors not handled by its chain are
+// written as plain text if the response was not started
+func writeErrors(h noodle.Handler) noodle.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		fw := &fallbackWriter{ResponseWriter: w}
+		err := h(ctx, fw, r)
+		if err != nil && !fw.written {
+			he := noodle.AsHTTPError(err)
+			http.Error(w, he.Message, he.Status)
+		}
+		return err
+	}
+}
+
+// fullPrefix returns path prefix of the group including prefixes of its parents
+func (wok *Wok) fullPrefix() string {
+	if wok.parent == nil {
+		return UrlJoin(wok.prefix)
+	}
+	return UrlJoin(wok.parent.fullPrefix(), wok.prefix)
+}
+
+// fullChain returns middleware chain of the group including chains of its parents
+func (wok *Wok) fullChain() noodle.Chain {
+	if wok.parent == nil {
+		return wok.chain
+	}
+	return wok.parent.fullChain().Use(wok.chain...)
+}
+
+// activeTracer returns tracer set on the group or its nearest parent
+func (wok *Wok) activeTracer() noodle.Tracer {
+	for router := wok; router != nil; router = router.parent {
+		if router.tracer != nil {
+			return router.tracer
+		}
+	}
+	return nil
+}
+
+// group returns the deepest group sharing router with the root whose prefix
+// matches the path, or the root itself
+func (t *routeTable) group(root *Wok, path string) *Wok {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res *Wok
+	var depth int
+	for _, g := range t.groups {
+		if g.routerRoot() != root {
+			continue
+		}
+		prefix := g.fullPrefix()
+		if prefix != "/" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if d := strings.Count(prefix, "/"); res == nil || d > depth || (d == depth && len(prefix) > len(res.fullPrefix())) {
+			res, depth = g, d
+		}
+	}
+	if res == nil {
+		return root
+	}
+	return res
+}
@@ -0,0 +1,116 @@
+package wok_test
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	mw "github.com/andviro/noodle/middleware"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/render"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+)
+
+func tagFactory(tag string) noodle.Middleware {
+	return func(next noodle.Handler) noodle.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			w.Header().Add("X-Tag", tag)
+			return next(ctx, w, r)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(mw.ErrorHandler, render.JSON, tagFactory("A"))
+	g := wk.Group("/api", tagFactory("G"))
+	g.GET("/items")(handlerFactory("B"))
+
+	res := noodletest.Get("/missing").Serve(t, wk).
+		Status(http.StatusNotFound).
+		JSON(map[string]string{"code": "not_found", "message": "Not Found"})
+	is.Equal(res.Recorder.Header()["X-Tag"], []string{"A"})
+	res = noodletest.Get("/api/missing").Serve(t, wk).Status(http.StatusNotFound)
+	is.Equal(res.Recorder.Header()["X-Tag"], []string{"A", "G"})
+	res = noodletest.Get("/apis").Serve(t, wk).Status(http.StatusNotFound)
+	is.Equal(res.Recorder.Header()["X-Tag"], []string{"A"})
+
+	g.NotFound(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return render.Yield(ctx, http.StatusGone, map[string]string{"path": r.URL.Path})
+	})
+	noodletest.Get("/api/missing").Serve(t, wk).
+		Status(http.StatusGone).
+		JSON(map[string]string{"path": "/api/missing"})
+	noodletest.Get("/missing").Serve(t, wk).Status(http.StatusNotFound)
+}
+
+func TestFallbackChainReused(t *testing.T) {
+	is := is.New(t)
+	var built int
+	counter := func(next noodle.Handler) noodle.Handler {
+		built++
+		return next
+	}
+	wk := wok.New(counter)
+	var traced int
+	wk.Trace(func(context.Context, noodle.TraceEvent) { traced++ })
+	for i := 0; i < 3; i++ {
+		noodletest.Get("/missing").Serve(t, wk).Status(http.StatusNotFound)
+	}
+	is.Equal(built, 1)
+	is.Equal(traced, 6)
+
+	wk.NotFound(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return noodle.NewHTTPError(http.StatusGone, "", "gone")
+	})
+	noodletest.Get("/missing").Serve(t, wk).Status(http.StatusGone)
+	noodletest.Get("/missing").Serve(t, wk).Status(http.StatusGone)
+	is.Equal(built, 2)
+	wk.Trace(nil)
+	noodletest.Get("/missing").Serve(t, wk).Status(http.StatusGone)
+	is.Equal(built, 3)
+	is.Equal(traced, 10)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/items")(handlerFactory("B"))
+	wk.POST("/items")(handlerFactory("C"))
+
+	res := noodletest.Delete("/items").Serve(t, wk).
+		Status(http.StatusMethodNotAllowed).
+		Body("Method Not Allowed\n")
+	is.Equal(res.Recorder.Header().Get("Allow"), "GET, OPTIONS, POST")
+
+	wk.MethodNotAllowed(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return noodle.NewHTTPError(http.StatusTeapot, "", "allowed: "+wok.Allow(ctx))
+	})
+	noodletest.Delete("/items").Serve(t, wk).
+		Status(http.StatusTeapot).
+		Body("allowed: GET, OPTIONS, POST\n")
+}
+
+func TestOptions(t *testing.T) {
+	is := is.New(t)
+	cors := func(next noodle.Handler) noodle.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if allow := wok.Allow(ctx); allow != "" {
+				w.Header().Set("Access-Control-Allow-Methods", allow)
+			}
+			return next(ctx, w, r)
+		}
+	}
+	wk := wok.New(cors)
+	wk.GET("/items")(handlerFactory("B"))
+
+	res := noodletest.NewRequest("OPTIONS", "/items").Serve(t, wk).Status(http.StatusOK).Body("")
+	is.Equal(res.Recorder.Header().Get("Access-Control-Allow-Methods"), "GET, OPTIONS")
+
+	wk.Options(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	noodletest.NewRequest("OPTIONS", "/items").Serve(t, wk).Status(http.StatusNoContent)
+}
@@ -1,9 +1,9 @@
 package wok
 
 import (
+	"context"
 	"github.com/andviro/noodle"
 	mw "github.com/andviro/noodle/middleware"
-	"golang.org/x/net/context"
 	"net/http"
 	"strings"
 )
@@ -13,16 +13,16 @@ type HandlerC interface {
 }
 
 // HandleC adapts handlers that implement ServeHTTPC method into Wok
-func (f RouteClosure) HandleC(h HandlerC) {
-	f(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+func (f RouteClosure) HandleC(h HandlerC) error {
+	return f(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		h.ServeHTTPC(ctx, w, r)
 		return nil
 	})
 }
 
 // HandleFuncC adapts functions compatible with ServeHTTPC signature into Wok
-func (f RouteClosure) HandleFuncC(h func(ctx context.Context, w http.ResponseWriter, r *http.Request)) {
-	f(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+func (f RouteClosure) HandleFuncC(h func(ctx context.Context, w http.ResponseWriter, r *http.Request)) error {
+	return f(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		h(ctx, w, r)
 		return nil
 	})
@@ -0,0 +1,166 @@
+package wok
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostGroup is a host pattern with its route group
+type hostGroup struct {
+	labels []string // pattern labels, "{name}" matches any single label
+	wok    *Wok
+}
+
+// match checks host labels against the pattern and returns host variables
+func (hg *hostGroup) match(labels []string) (Params, bool) {
+	if len(labels) != len(hg.labels) {
+		return nil, false
+	}
+	var res Params
+	for i, l := range hg.labels {
+		if strings.HasPrefix(l, "{") && strings.HasSuffix(l, "}") {
+			res = append(res, Param{Key: l[1 : len(l)-1], Value: labels[i]})
+		} else if l != labels[i] {
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+// hostTable lists host groups in order of matching
+type hostTable struct {
+	hosts []*hostGroup
+	// defaultHost serves requests for unknown hosts
+	defaultHost *Wok
+}
+
+// Host starts new route group served only for requests to the host matching
+// the pattern. Pattern labels in curly braces, such as "{tenant}.example.com",
+// match any single label and are available through HostVar. Host groups have
+// their own routes, while router middlewares apply to them as well. Patterns
+// without variables take precedence, others are matched in order of
+// registration. Requests for unknown hosts are served by routes registered
+// outside host groups, see DefaultHost.
+func (wok *Wok) Host(pattern string, mws ...noodle.Middleware) *Wok {
+	pattern = normalizeHost(pattern)
+	res := &Wok{
+		host:   pattern,
+		parent: wok,
+		Router: NewRouter(),
+		chain:  noodle.New(mws...),
+		routes: wok.routes,
+	}
+	res.Router.NotFound = res.fallback(notFound)
+	res.Router.MethodNotAllowed = res.fallback(methodNotAllowed)
+	res.Router.GlobalOPTIONS = res.fallback(options)
+	wok.routes.addHost(&hostGroup{labels: strings.Split(pattern, "."), wok: res})
+	wok.routes.addGroup(res)
+	return res
+}
+
+// DefaultHost makes requests for unknown hosts served by the host group
+// instead of routes registered outside host groups
+func (wok *Wok) DefaultHost(g *Wok) {
+	t := wok.routes
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := t.hostTable()
+	res.defaultHost = g
+	t.hosts.Store(&res)
+}
+
+// HostVar returns host variable for context or empty string
+func HostVar(c context.Context, name string) string {
+	p, _ := c.Value(hostKey).(Params)
+	return p.ByName(name)
+}
+
+// ServeHTTP runs pre-routing middlewares and dispatches request to the router
+// of the matching host group or to the default one
+func (wok *Wok) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	root := wok
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.preRoute(w, r, func(w http.ResponseWriter, r *http.Request) {
+		router, params := wok.routes.host(r.Host)
+		if router == nil {
+			router = root
+		}
+		if params != nil {
+			r = r.WithContext(context.WithValue(r.Context(), hostKey, params))
+		}
+		if router == root {
+			router.live().ServeHTTP(w, r)
+			return
+		}
+		router.preRoute(w, r, router.live().ServeHTTP)
+	})
+}
+
+// routerRoot returns the outermost group sharing Router with the
+// group, such as host group or the root router
+func (wok *Wok) routerRoot() *Wok {
+	router := wok
+	for router.parent != nil && router.parent.Router == router.Router {
+		router = router.parent
+	}
+	return router
+}
+
+// activeHost returns host pattern of the group or its nearest parent
+func (wok *Wok) activeHost() string {
+	for router := wok; router != nil; router = router.parent {
+		if router.host != "" {
+			return router.host
+		}
+	}
+	return ""
+}
+
+func (t *routeTable) addHost(hg *hostGroup) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := t.hostTable()
+	if !strings.Contains(strings.Join(hg.labels, "."), "{") {
+		res.hosts = append([]*hostGroup{hg}, res.hosts...)
+	} else {
+		res.hosts = append(res.hosts[:len(res.hosts):len(res.hosts)], hg)
+	}
+	t.hosts.Store(&res)
+}
+
+// hostTable returns copy of the current host table
+func (t *routeTable) hostTable() hostTable {
+	if ht := t.hosts.Load(); ht != nil {
+		return *ht
+	}
+	return hostTable{}
+}
+
+// host finds group for the request host, nil is returned for unknown hosts
+// when no default host is set
+func (t *routeTable) host(host string) (*Wok, Params) {
+	ht := t.hosts.Load()
+	if ht == nil || len(ht.hosts) == 0 {
+		return nil, nil
+	}
+	labels := strings.Split(normalizeHost(host), ".")
+	for _, hg := range ht.hosts {
+		if params, ok := hg.match(labels); ok {
+			return hg.wok, params
+		}
+	}
+	return ht.defaultHost, nil
+}
+
+// normalizeHost strips port and trailing dot and converts host to lower case
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -0,0 +1,43 @@
+package wok_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(mwFactory("A"))
+	wk.GET("/")(handlerFactory("root"))
+	api := wk.Host("API.example.com", mwFactory("API"))
+	api.GET("/")(handlerFactory("api"))
+	tenant := wk.Host("{tenant}.example.com", mwFactory("T"))
+	tenant.Group("/g", mwFactory("G")).GET("/:id")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprintf(w, "[%s %s]", wok.HostVar(ctx, "tenant"), wok.Var(ctx, "id"))
+		return nil
+	})
+
+	noodletest.Get("http://api.example.com:8080/").Serve(t, wk).Body("A>API>[api]")
+	noodletest.Get("http://acme.example.com/g/12").Serve(t, wk).Body("A>T>G>[acme 12]")
+	noodletest.Get("http://acme.example.com/").Serve(t, wk).Body("A>T>")
+	noodletest.Get("http://example.com/").Serve(t, wk).Body("A>[root]")
+	noodletest.Get("http://a.b.example.com/g/12").Serve(t, wk).Body("A>")
+
+	wk.DefaultHost(api)
+	noodletest.Get("http://example.com/").Serve(t, wk).Body("A>API>[api]")
+
+	routes := wk.Routes()
+	is.Equal(routes[1].Host, "api.example.com")
+	is.Equal(routes[2].Host, "{tenant}.example.com")
+	is.Equal(routes[2].Groups, []string{"/g"})
+	var buf bytes.Buffer
+	is.NotErr(routes.WriteText(&buf))
+	is.True(strings.Contains(buf.String(), "{tenant}.example.com/g/:id"))
+}
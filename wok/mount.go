@@ -0,0 +1,85 @@
+package wok
+
+import (
+	"context"
+	"fmt"
+	"github.com/andviro/noodle"
+	"net/http"
+	"strings"
+)
+
+// mountParam is the name of catch-all parameter of mounted routes
+const mountParam = "mount"
+
+// MountMethods lists HTTP methods routed to mounted handlers
+var MountMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// Mount attaches http.Handler, such as another Wok, under the prefix. Requests
+// to the prefix and all paths below it pass through the middleware chain of
+// the router and mws, then are served by the handler with the prefix stripped
+// from URL.Path and URL.RawPath. Mounted routes are excluded from OpenAPI
+// documents unless Op option says otherwise. Conflicts with existing routes
+// are reported as error.
+func (wok *Wok) Mount(prefix string, h http.Handler, mws ...noodle.Middleware) error {
+	route := &Route{Method: "*", Handler: handlerName(h), Operation: &Operation{Hidden: true}}
+	chain := wok.prepare(route, UrlJoin(prefix, "*"+mountParam), mws)
+	base := strings.TrimSuffix(route.Path, "/*"+mountParam)
+	handle := wok.convert(chain.Then(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		h.ServeHTTP(w, stripPrefix(ctx, r, Var(ctx, mountParam)))
+		return nil
+	}), route)
+	paths := []string{route.Path}
+	if base != "" {
+		paths = append(paths, base)
+	}
+	return wok.register(route, MountMethods, paths, handle)
+}
+
+// OriginalPath returns request path before the prefix was stripped by Mount.
+// Empty string is returned outside of mounted handlers.
+func OriginalPath(c context.Context) string {
+	res, _ := c.Value(originalPathKey).(string)
+	return res
+}
+
+// stripPrefix returns copy of the request with path rest remaining after the
+// mount prefix and original path stored in the context
+func stripPrefix(ctx context.Context, r *http.Request, rest string) *http.Request {
+	if rest == "" {
+		rest = "/"
+	}
+	if OriginalPath(ctx) == "" {
+		ctx = context.WithValue(ctx, originalPathKey, r.URL.Path)
+	}
+	res := r.WithContext(ctx)
+	u := *r.URL
+	u.Path = rest
+	if r.URL.RawPath != "" {
+		// prefix has the same number of segments in escaped and unescaped form
+		prefix := strings.TrimSuffix(r.URL.Path, strings.TrimPrefix(rest, "/"))
+		u.RawPath = "/" + skipSegments(r.URL.RawPath, strings.Count(strings.TrimSuffix(prefix, "/"), "/"))
+	}
+	res.URL = &u
+	return res
+}
+
+// skipSegments removes n leading segments from the slash-separated path
+func skipSegments(path string, n int) string {
+	path = strings.TrimPrefix(path, "/")
+	for ; n > 0; n-- {
+		i := strings.Index(path, "/")
+		if i < 0 {
+			return ""
+		}
+		path = path[i+1:]
+	}
+	return path
+}
+
+// handlerName describes http.Handler for the route table
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		return funcName(f)
+	}
+	return fmt.Sprintf("%T", h)
+}
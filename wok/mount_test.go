@@ -0,0 +1,57 @@
+package wok_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	is := is.New(t)
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%s %s %s %s]", r.Method, r.URL.Path, r.URL.RawPath, wok.OriginalPath(r.Context()))
+	}
+	wk := wok.New(mwFactory("A"))
+	g := wk.Group("/g", mwFactory("G"))
+	g.Mount("/legacy", http.HandlerFunc(echo), mwFactory("M"), wok.Name("legacy"))
+
+	noodletest.Get("/g/legacy").Serve(t, wk).Body("A>G>M>[GET /  /g/legacy]")
+	noodletest.Get("/g/legacy/").Serve(t, wk).Body("A>G>M>[GET /  /g/legacy/]")
+	noodletest.Post("/g/legacy/a/b").Serve(t, wk).Body("A>G>M>[POST /a/b  /g/legacy/a/b]")
+	noodletest.Get("/g/legacy/a%2Fb/c").Serve(t, wk).Body("A>G>M>[GET /a/b/c /a%2Fb/c /g/legacy/a/b/c]")
+
+	url, err := wk.URL("legacy", "mount", "x/y")
+	is.NotErr(err)
+	is.Equal(url, "/g/legacy/x/y")
+
+	routes := wk.Routes()
+	is.Equal(len(routes), 1)
+	is.Equal(routes[0].Method, "*")
+	is.Equal(routes[0].Path, "/g/legacy/*mount")
+	is.Equal(routes[0].Handler, "wok_test.TestMount.func1")
+	doc, err := wk.OpenAPI(wok.OpenAPIInfo{})
+	is.NotErr(err)
+	is.Equal(len(doc.Paths), 0)
+}
+
+func TestMountWok(t *testing.T) {
+	is := is.New(t)
+	child := wok.New(tagFactory("C"))
+	child.GET("/")(handlerFactory("index"))
+	child.GET("/items/:id")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprintf(w, "[%s %s]", wok.Var(ctx, "id"), wok.OriginalPath(ctx))
+		return nil
+	})
+	wk := wok.New(tagFactory("A"))
+	wk.Mount("/child", child)
+
+	noodletest.Get("/child").Serve(t, wk).Body("[index]")
+	noodletest.Get("/child/items/12").Serve(t, wk).Body("[12 /child/items/12]")
+	is.Equal(wk.Routes()[0].Handler, "*wok.Wok")
+	res := noodletest.Get("/child/missing").Serve(t, wk).Status(http.StatusNotFound).Body("Not Found\n")
+	is.Equal(res.Recorder.Header()["X-Tag"], []string{"A", "C"})
+}
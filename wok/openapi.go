@@ -0,0 +1,447 @@
+package wok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/bind"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation holds OpenAPI metadata of the route
+type Operation struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Hidden      bool                // exclude route from the document
+	Request     interface{}         // request body model, inferred from bind middleware if nil
+	Responses   map[int]interface{} // response body models by status, nil model means empty body
+}
+
+// Op is a route option that attaches OpenAPI operation metadata to the route
+func Op(op Operation) noodle.Middleware {
+	return option("wok.Op", func(r *Route) {
+		declared := r.operation().Responses
+		*r.Operation = op
+		r.Operation.Responses = nil
+		for code, model := range op.Responses {
+			r.returns(code, model)
+		}
+		for code, model := range declared {
+			r.returns(code, model)
+		}
+	})
+}
+
+// Returns is a route option that declares response status and body model of the route
+func Returns(code int, model interface{}) noodle.Middleware {
+	return option("wok.Returns", func(r *Route) {
+		r.returns(code, model)
+	})
+}
+
+// returns declares response of the route operation
+func (r *Route) returns(code int, model interface{}) {
+	op := r.operation()
+	if op.Responses == nil {
+		op.Responses = make(map[int]interface{})
+	}
+	op.Responses[code] = model
+}
+
+func (r *Route) operation() *Operation {
+	if r.Operation == nil {
+		r.Operation = new(Operation)
+	}
+	return r.Operation
+}
+
+// ErrHostConflict is returned by OpenAPI when host groups register the same
+// route, because OpenAPI paths can't be distinguished by host
+var ErrHostConflict = errors.New("route is documented for another host")
+
+// OpenAPIInfo describes the API in OpenAPI document
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIDocument is OpenAPI 3 document describing routes of the router
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	} `json:"components"`
+	names map[reflect.Type]string // component names of described types
+}
+
+// OpenAPIOperation describes single API operation on a path
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes operation parameter
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes operation request body
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes operation response
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds schema of request or response body
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a subset of OpenAPI schema object sufficient to describe Go types
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// OpenAPI generates OpenAPI 3 document for the routes registered so far.
+// Routes with the same method and path registered for different hosts are
+// reported as ErrHostConflict.
+func (wok *Wok) OpenAPI(info OpenAPIInfo) (*OpenAPIDocument, error) {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+		names:   make(map[reflect.Type]string),
+	}
+	doc.Components.Schemas = make(map[string]*Schema)
+	hosts := make(map[string]string)
+	for _, r := range wok.Routes() {
+		if r.Operation != nil && r.Operation.Hidden {
+			continue
+		}
+		path, params := openAPIPath(r.Path, r.Constraints)
+		method := strings.ToLower(r.Method)
+		if host, ok := hosts[method+" "+path]; ok && host != r.Host {
+			return nil, &RouteError{Method: r.Method, Path: r.Host + r.Path, Err: ErrHostConflict}
+		}
+		hosts[method+" "+path] = r.Host
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[path][method] = doc.operation(r, params)
+	}
+	return doc, nil
+}
+
+// openAPIPath converts route path into OpenAPI path template and lists its parameters
+func openAPIPath(path string, constraints map[string]string) (string, []*OpenAPIParameter) {
+	var params []*OpenAPIParameter
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if s == "" || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		segments[i] = "{" + s[1:] + "}"
+		params = append(params, &OpenAPIParameter{Name: s[1:], In: "path", Required: true, Schema: paramSchema(constraints[s[1:]])})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// paramSchema describes path parameter with constraint
+func paramSchema(constraint string) *Schema {
+	switch constraint {
+	case "":
+		return &Schema{Type: "string"}
+	case "int":
+		return &Schema{Type: "integer"}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	return &Schema{Type: "string", Pattern: "^(?:" + constraint + ")$"}
+}
+
+func (doc *OpenAPIDocument) operation(r Route, params []*OpenAPIParameter) *OpenAPIOperation {
+	op := r.Operation
+	if op == nil {
+		op = new(Operation)
+	}
+	res := &OpenAPIOperation{
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Parameters:  params,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+	if res.OperationID == "" {
+		res.OperationID = r.Name
+	}
+	if res.Tags == nil {
+		res.Tags = r.Tags
+	}
+	if op.Request != nil {
+		res.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]*OpenAPIMediaType{
+			"application/json": {doc.schema(reflect.TypeOf(op.Request))},
+		}}
+	} else {
+		for _, mw := range r.Middlewares {
+			model, ok := mw.Meta[bind.ModelMeta].(reflect.Type)
+			if !ok {
+				continue
+			}
+			contentType, _ := mw.Meta[bind.ContentTypeMeta].(string)
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			res.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]*OpenAPIMediaType{
+				contentType: {doc.schema(model)},
+			}}
+		}
+	}
+	for code, model := range op.Responses {
+		resp := &OpenAPIResponse{Description: http.StatusText(code)}
+		if model != nil {
+			resp.Content = map[string]*OpenAPIMediaType{
+				"application/json": {doc.schema(reflect.TypeOf(model))},
+			}
+		}
+		res.Responses[strconv.Itoa(code)] = resp
+	}
+	if len(res.Responses) == 0 {
+		res.Responses["default"] = &OpenAPIResponse{Description: "Default response"}
+	}
+	return res
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns schema of Go type. Named struct types are put into document
+// components and referenced.
+func (doc *OpenAPIDocument) schema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		res := *doc.schema(t.Elem())
+		if res.Ref == "" {
+			res.Nullable = true
+		}
+		return &res
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: doc.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: doc.schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return doc.structSchema(t)
+		}
+		name, ok := doc.names[t]
+		if !ok {
+			name = doc.componentName(t)
+			doc.names[t] = name
+			doc.Components.Schemas[name] = &Schema{} // placeholder for recursive types
+			doc.Components.Schemas[name] = doc.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// componentName returns type name not taken by other types, same-named types
+// from different packages get numeric suffix
+func (doc *OpenAPIDocument) componentName(t reflect.Type) string {
+	res := t.Name()
+	for i := 2; doc.Components.Schemas[res] != nil; i++ {
+		res = t.Name() + strconv.Itoa(i)
+	}
+	return res
+}
+
+// structSchema describes struct fields as they are serialized by encoding/json
+func (doc *OpenAPIDocument) structSchema(t reflect.Type) *Schema {
+	res := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts := f.Name, ""
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if idx := strings.IndexByte(tag, ','); idx >= 0 {
+				tag, opts = tag[:idx], tag[idx:]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := doc.structSchema(ft)
+				for k, v := range embedded.Properties {
+					res.Properties[k] = v
+				}
+				res.Required = append(res.Required, embedded.Required...)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		res.Properties[name] = doc.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			res.Required = append(res.Required, name)
+		}
+	}
+	sort.Strings(res.Required)
+	return res
+}
+
+// WriteJSON writes document as indented JSON
+func (doc *OpenAPIDocument) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteYAML writes document as YAML
+func (doc *OpenAPIDocument) WriteYAML(w io.Writer) error {
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(buf)))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeYAML(&b, tree, "")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAML emits block YAML for a tree decoded from JSON. Strings are
+// written double-quoted, which makes JSON escapes valid.
+func writeYAML(b *strings.Builder, v interface{}, indent string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(indent + strconv.Quote(k) + ":")
+			writeYAMLValue(b, t[k], indent)
+		}
+	case []interface{}:
+		for _, item := range t {
+			b.WriteString(indent + "-")
+			writeYAMLValue(b, item, indent)
+		}
+	}
+}
+
+func writeYAMLValue(b *strings.Builder, v interface{}, indent string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, t, indent+"  ")
+	case []interface{}:
+		if len(t) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, t, indent+"  ")
+	case string:
+		b.WriteString(" " + strconv.Quote(t) + "\n")
+	case json.Number:
+		b.WriteString(" " + t.String() + "\n")
+	case bool:
+		b.WriteString(" " + strconv.FormatBool(t) + "\n")
+	default:
+		b.WriteString(" null\n")
+	}
+}
+
+// ServeOpenAPI registers GET route that serves OpenAPI document of the
+// router. Document is written as YAML if path ends with .yaml or .yml, JSON
+// otherwise. The route itself is not included into the document. Error is
+// returned if the route conflicts with existing ones.
+func (wok *Wok) ServeOpenAPI(path string, info OpenAPIInfo, mws ...noodle.Middleware) error {
+	yaml := strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+	mws = append([]noodle.Middleware{Op(Operation{Hidden: true})}, mws...)
+	return wok.GET(path, mws...)(func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		doc, err := wok.OpenAPI(info)
+		if err != nil {
+			return err
+		}
+		if yaml {
+			w.Header().Set("Content-Type", "application/yaml;charset=utf-8")
+			return doc.WriteYAML(w)
+		}
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		return doc.WriteJSON(w)
+	})
+}
@@ -0,0 +1,167 @@
+package wok_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/andviro/noodle/bind"
+	"github.com/andviro/noodle/render"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Base struct {
+	ID int64 `json:"id"`
+}
+
+type Item struct {
+	Base
+	Name     string            `json:"name"`
+	Tags     []string          `json:"tags,omitempty"`
+	Parent   *Item             `json:"parent,omitempty"`
+	Created  time.Time         `json:"created"`
+	Attrs    map[string]string `json:"attrs"`
+	Data     []byte            `json:"data"`
+	Internal string            `json:"-"`
+	secret   string
+}
+
+type itemForm struct {
+	Name string `json:"name" form:"name"`
+}
+
+func openAPIFixture() *wok.Wok {
+	wk := wok.New()
+	api := wk.Group("/api", render.JSON)
+	api.GET("/items/:id", wok.Name("getItem"), wok.Returns(200, Item{}), wok.Returns(404, nil))(listItems)
+	api.POST("/items", bind.JSON(Item{}), wok.Op(wok.Operation{Summary: "Create item", Tags: []string{"items"}}), wok.Returns(201, Item{}))(listItems)
+	api.PUT("/items/:id", bind.Form(itemForm{}))(listItems)
+	api.GET("/files/*path", wok.Op(wok.Operation{OperationID: "getFile", Deprecated: true}))(listItems)
+	api.GET("/hidden", wok.Op(wok.Operation{Hidden: true}))(listItems)
+	return wk
+}
+
+func TestOpenAPI(t *testing.T) {
+	is := is.New(t)
+	doc, err := openAPIFixture().OpenAPI(wok.OpenAPIInfo{Title: "Test", Version: "1.0"})
+	is.NotErr(err)
+	is.Equal(doc.OpenAPI, "3.0.3")
+	is.Equal(len(doc.Paths), 3)
+
+	get := doc.Paths["/api/items/{id}"]["get"]
+	is.Equal(get.OperationID, "getItem")
+	is.Equal(len(get.Parameters), 1)
+	is.Equal(get.Parameters[0].Name, "id")
+	is.Equal(get.Parameters[0].In, "path")
+	is.True(get.Parameters[0].Required)
+	is.Equal(get.Responses["200"].Content["application/json"].Schema.Ref, "#/components/schemas/Item")
+	is.Equal(get.Responses["404"].Description, "Not Found")
+	is.Nil(get.Responses["404"].Content)
+
+	post := doc.Paths["/api/items"]["post"]
+	is.Equal(post.Summary, "Create item")
+	is.Equal(post.Tags, []string{"items"})
+	is.Equal(post.RequestBody.Content["application/json"].Schema.Ref, "#/components/schemas/Item")
+	is.Equal(post.Responses["201"].Description, "Created")
+
+	put := doc.Paths["/api/items/{id}"]["put"]
+	is.NotNil(put.RequestBody.Content["application/x-www-form-urlencoded"])
+	is.Equal(put.Responses["default"].Description, "Default response")
+
+	file := doc.Paths["/api/files/{path}"]["get"]
+	is.Equal(file.OperationID, "getFile")
+	is.True(file.Deprecated)
+
+	item := doc.Components.Schemas["Item"]
+	is.Equal(item.Type, "object")
+	is.Equal(len(item.Properties), 7)
+	is.Equal(item.Properties["id"].Format, "int64")
+	is.Equal(item.Properties["tags"].Items.Type, "string")
+	is.Equal(item.Properties["parent"].Ref, "#/components/schemas/Item")
+	is.Equal(item.Properties["created"].Format, "date-time")
+	is.Equal(item.Properties["attrs"].AdditionalProperties.Type, "string")
+	is.Equal(item.Properties["data"].Format, "byte")
+	is.Equal(item.Required, []string{"attrs", "created", "data", "id", "name"})
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	is := is.New(t)
+	wk := openAPIFixture()
+	is.NotErr(wk.ServeOpenAPI("/openapi.json", wok.OpenAPIInfo{Title: "Test", Version: "1.0"}))
+	is.NotErr(wk.ServeOpenAPI("/openapi.yaml", wok.OpenAPIInfo{Title: "Test", Version: "1.0"}))
+	is.True(errors.Is(wk.ServeOpenAPI("/openapi.json", wok.OpenAPIInfo{}), wok.ErrDuplicate))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://localhost/openapi.json", nil)
+	wk.ServeHTTP(w, r)
+	is.Equal(w.Header().Get("Content-Type"), "application/json;charset=utf-8")
+	var doc map[string]interface{}
+	is.NotErr(json.Unmarshal(w.Body.Bytes(), &doc))
+	is.Equal(doc["info"], map[string]interface{}{"title": "Test", "version": "1.0"})
+	is.Equal(len(doc["paths"].(map[string]interface{})), 3)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "http://localhost/openapi.yaml", nil)
+	wk.ServeHTTP(w, r)
+	is.Equal(w.Header().Get("Content-Type"), "application/yaml;charset=utf-8")
+	yaml := w.Body.String()
+	is.True(strings.HasPrefix(yaml, "\"components\":\n  \"schemas\":\n"))
+	is.True(strings.Contains(yaml, "\n\"openapi\": \"3.0.3\"\n"))
+	is.True(strings.Contains(yaml, "\n      \"parameters\":\n        -\n          \"in\": \"path\"\n"))
+	is.True(strings.Contains(yaml, "\"required\": true\n"))
+}
+
+func TestOpenAPIYAMLEmptyCollections(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	doc, err := wok.New().OpenAPI(wok.OpenAPIInfo{Title: "Empty"})
+	is.NotErr(err)
+	is.NotErr(doc.WriteYAML(buf))
+	is.Equal(buf.String(), "\"components\": {}\n\"info\":\n  \"title\": \"Empty\"\n  \"version\": \"\"\n\"openapi\": \"3.0.3\"\n\"paths\": {}\n")
+}
+
+func TestOpenAPISchemaNames(t *testing.T) {
+	is := is.New(t)
+	type Item struct {
+		Int    int    `json:"int"`
+		Uint32 uint32 `json:"uint32"`
+		Int16  int16  `json:"int16"`
+	}
+	wk := wok.New()
+	wk.GET("/b", wok.Returns(200, Item{}))(listItems)
+	wk.GET("/c", wok.Returns(200, []Item{}))(listItems)
+	doc, err := wk.OpenAPI(wok.OpenAPIInfo{})
+	is.NotErr(err)
+	is.Equal(doc.Paths["/b"]["get"].Responses["200"].Content["application/json"].Schema.Ref, "#/components/schemas/Item")
+	is.Equal(doc.Paths["/c"]["get"].Responses["200"].Content["application/json"].Schema.Items.Ref, "#/components/schemas/Item")
+
+	wk.GET("/d", wok.Returns(200, openAPIItem()))(listItems)
+	doc, err = wk.OpenAPI(wok.OpenAPIInfo{})
+	is.NotErr(err)
+	is.Equal(len(doc.Components.Schemas), 2)
+	is.Equal(doc.Paths["/d"]["get"].Responses["200"].Content["application/json"].Schema.Ref, "#/components/schemas/Item2")
+	item := doc.Components.Schemas["Item"]
+	is.Equal(item.Properties["int"].Format, "int64")
+	is.Equal(item.Properties["uint32"].Format, "int64")
+	is.Equal(item.Properties["int16"].Format, "int32")
+}
+
+// openAPIItem returns value of package level Item type
+func openAPIItem() interface{} {
+	return Item{}
+}
+
+func TestOpenAPIHostConflict(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.Host("api.example.com").GET("/users")(listItems)
+	wk.Host("admin.example.com").GET("/users")(listItems)
+	_, err := wk.OpenAPI(wok.OpenAPIInfo{})
+	is.True(errors.Is(err, wok.ErrHostConflict))
+	is.Equal(err.Error(), "wok: GET admin.example.com/users: route is documented for another host")
+}
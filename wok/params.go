@@ -0,0 +1,144 @@
+package wok
+
+import (
+	"context"
+	"fmt"
+	"github.com/andviro/noodle"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BadParam is returned by typed parameter accessors
+var BadParam = noodle.NewHTTPError(http.StatusBadRequest, "bad_param", "Bad route parameter")
+
+// ParamError describes missing or malformed route parameter
+type ParamError struct {
+	Name  string
+	Value string
+	Err   error // parsing error, nil if parameter is missing
+}
+
+func (e *ParamError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("Parameter `%s` is missing", e.Name)
+	}
+	return fmt.Sprintf("Parameter `%s` is malformed: %v", e.Name, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// constraint restricts values of route parameter
+type constraint struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var (
+	intRe  = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// parseConstraints strips parameter constraints such as ":id<int>",
+// ":id<uuid>" or ":slug<[a-z-]+>" from the path. Other constraints are
+// regular expressions that must match the whole value. Malformed regular
+// expressions are reported as ErrBadPattern.
+func parseConstraints(path string) (string, map[string]string, []constraint, error) {
+	var specs map[string]string
+	var res []constraint
+	var bad error
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if s == "" || (s[0] != ':' && s[0] != '*') || !strings.HasSuffix(s, ">") {
+			continue
+		}
+		open := strings.IndexByte(s, '<')
+		if open < 0 {
+			continue
+		}
+		name, spec := s[1:open], s[open+1:len(s)-1]
+		segments[i] = s[:open]
+		if specs == nil {
+			specs = make(map[string]string)
+		}
+		specs[name] = spec
+		var re *regexp.Regexp
+		switch spec {
+		case "int":
+			re = intRe
+		case "uuid":
+			re = uuidRe
+		default:
+			var err error
+			if re, err = regexp.Compile("^(?:" + spec + ")$"); err != nil {
+				if bad == nil {
+					bad = fmt.Errorf("%w: invalid constraint of parameter '%s': %v", ErrBadPattern, name, err)
+				}
+				continue
+			}
+		}
+		res = append(res, constraint{name: name, re: re})
+	}
+	return strings.Join(segments, "/"), specs, res, bad
+}
+
+// check tests parameters against constraints
+func check(cs []constraint, p Params) bool {
+	for _, c := range cs {
+		if !c.re.MatchString(p.ByName(c.name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Vars returns all route variables for context
+func Vars(c context.Context) map[string]string {
+	p, _ := c.Value(paramKey).(Params)
+	res := make(map[string]string, len(p))
+	for _, v := range p {
+		res[v.Key] = v.Value
+	}
+	return res
+}
+
+// lookup returns route variable and reports whether it's present
+func lookup(c context.Context, name string) (string, bool) {
+	p, _ := c.Value(paramKey).(Params)
+	for _, v := range p {
+		if v.Key == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// IntVar returns route variable converted to int. Returned error wraps
+// ParamError into BadParam.
+func IntVar(c context.Context, name string) (int, error) {
+	v, ok := lookup(c, name)
+	if !ok {
+		return 0, BadParam.Wrap(&ParamError{Name: name})
+	}
+	res, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, BadParam.Wrap(&ParamError{Name: name, Value: v, Err: err})
+	}
+	return res, nil
+}
+
+// UUIDVar returns route variable in canonical lower case UUID form. Returned
+// error wraps ParamError into BadParam.
+func UUIDVar(c context.Context, name string) (string, error) {
+	v, ok := lookup(c, name)
+	if !ok {
+		return "", BadParam.Wrap(&ParamError{Name: name})
+	}
+	if !uuidRe.MatchString(v) {
+		return "", BadParam.Wrap(&ParamError{Name: name, Value: v, Err: fmt.Errorf("invalid UUID %q", v)})
+	}
+	return strings.ToLower(v), nil
+}
@@ -0,0 +1,102 @@
+package wok_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/users/:id<int>/files/:file<[a-z]+\\.txt>", wok.Name("file"))(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id, err := wok.IntVar(ctx, "id")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "[%d %s]", id, wok.Var(ctx, "file"))
+		return nil
+	})
+	wk.GET("/items/:id<uuid>")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id, err := wok.UUIDVar(ctx, "id")
+		fmt.Fprintf(w, "[%s %v %v]", id, err, wok.Vars(ctx))
+		return nil
+	})
+
+	noodletest.Get("/users/12/files/a.txt").Serve(t, wk).Body("[12 a.txt]")
+	noodletest.Get("/users/x/files/a.txt").Serve(t, wk).Status(http.StatusNotFound)
+	noodletest.Get("/users/12/files/a.exe").Serve(t, wk).Status(http.StatusNotFound)
+	noodletest.Get("/items/0EE51A1C-36A0-4B8D-9F6B-2C3F7E9A1D02").Serve(t, wk).
+		Body("[0ee51a1c-36a0-4b8d-9f6b-2c3f7e9a1d02 <nil> map[id:0EE51A1C-36A0-4B8D-9F6B-2C3F7E9A1D02]]")
+	noodletest.Get("/items/12").Serve(t, wk).Status(http.StatusNotFound)
+
+	url, err := wk.URL("file", "id", "1", "file", "b.txt")
+	is.NotErr(err)
+	is.Equal(url, "/users/1/files/b.txt")
+	route := wk.Routes()[0]
+	is.Equal(route.Path, "/users/:id/files/:file")
+	is.Equal(route.Constraints, map[string]string{"id": "int", "file": "[a-z]+\\.txt"})
+
+	doc, err := wk.OpenAPI(wok.OpenAPIInfo{})
+	is.NotErr(err)
+	params := doc.Paths["/users/{id}/files/{file}"]["get"].Parameters
+	is.Equal(params[0].Schema.Type, "integer")
+	is.Equal(params[1].Schema.Pattern, "^(?:[a-z]+\\.txt)$")
+	is.Equal(doc.Paths["/items/{id}"]["get"].Parameters[0].Schema.Format, "uuid")
+}
+
+func TestInvalidConstraint(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	err := wk.GET("/x/:id<[>")(handlerFactory("x"))
+	is.True(errors.Is(err, wok.ErrBadPattern))
+	is.True(strings.HasPrefix(err.Error(), "wok: GET /x/:id: malformed route path: invalid constraint of parameter 'id': "))
+	noodletest.Get("/x/1").Serve(t, wk).Status(http.StatusNotFound)
+}
+
+func TestVarsOutliveRequest(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	var saved []context.Context
+	wk.GET("/a/:id")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		saved = append(saved, context.WithoutCancel(ctx))
+		return nil
+	})
+	noodletest.Get("/a/1").Serve(t, wk)
+	noodletest.Get("/a/2").Serve(t, wk)
+	is.Equal(wok.Var(saved[0], "id"), "1")
+	is.Equal(wok.Var(saved[1], "id"), "2")
+}
+
+func TestVars(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	is.Equal(wok.Var(ctx, "id"), "")
+	is.Equal(wok.Vars(ctx), map[string]string{})
+
+	_, err := wok.IntVar(ctx, "id")
+	is.True(errors.Is(err, wok.BadParam))
+	var pe *wok.ParamError
+	is.True(errors.As(err, &pe))
+	is.Equal(pe.Name, "id")
+	is.Equal(err.Error(), "Bad route parameter: Parameter `id` is missing")
+	is.Equal(noodle.AsHTTPError(err).Status, http.StatusBadRequest)
+
+	wk := wok.New()
+	wk.GET("/:id")(noodletest.Capture(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		_, err := wok.IntVar(ctx, "id")
+		return err
+	}))
+	res := noodletest.Get("/abc").Serve(t, wk)
+	is.True(errors.As(res.Err, &pe))
+	is.Equal(pe.Value, "abc")
+	_, err = wok.UUIDVar(res.Context, "id")
+	is.True(errors.Is(err, wok.BadParam))
+}
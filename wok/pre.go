@@ -0,0 +1,33 @@
+package wok
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"net/http"
+)
+
+// Pre adds middlewares that run on every request before route lookup. They may
+// rewrite request path or method to affect routing, or respond on their own
+// without calling the next handler. Pre-routing middlewares belong to the
+// router of the group: root router, host group or API version. Those of the
+// root router run before host group is selected. Errors returned from
+// pre-routing middlewares are written as plain text if nothing was written.
+func (wok *Wok) Pre(mws ...noodle.Middleware) {
+	router := wok.routerRoot()
+	router.pre = router.pre.Use(mws...)
+}
+
+// preRoute runs pre-routing middlewares of the group and passes request with
+// resulting context to next
+func (wok *Wok) preRoute(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if len(wok.pre) == 0 {
+		next(w, r)
+		return
+	}
+	ctx, cancel := layer(r.Context(), wok.context())
+	defer cancel()
+	_ = writeErrors(wok.pre.Then(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		next(w, r.WithContext(ctx))
+		return nil
+	}))(ctx, w, r)
+}
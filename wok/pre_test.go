@@ -0,0 +1,65 @@
+package wok_test
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func trimSlash(next noodle.Handler) noodle.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Path != "/" {
+			r.URL.Path = strings.TrimSuffix(r.URL.Path, "/")
+		}
+		return next(ctx, w, r)
+	}
+}
+
+func methodOverride(next noodle.Handler) noodle.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if m := r.Header.Get("X-HTTP-Method-Override"); m != "" && r.Method == "POST" {
+			r.Method = m
+		}
+		return next(context.WithValue(ctx, "pre", "overridden"), w, r)
+	}
+}
+
+func canonicalHost(next noodle.Handler) noodle.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if r.Host == "legacy.example.com" {
+			http.Redirect(w, r, "http://example.com"+r.URL.Path, http.StatusMovedPermanently)
+			return nil
+		}
+		if r.Host == "www.example.com" {
+			r.Host = "api.example.com"
+		}
+		if r.URL.Path == "/forbidden" {
+			return noodle.NewHTTPError(http.StatusForbidden, "", "")
+		}
+		return next(ctx, w, r)
+	}
+}
+
+func TestPre(t *testing.T) {
+	wk := wok.New(tagFactory("A"))
+	wk.Pre(trimSlash, methodOverride)
+	wk.Pre(canonicalHost)
+	wk.GET("/users")(handlerFactory("list"))
+	wk.DELETE("/users/:id")(handlerFactory("pre"))
+	api := wk.Host("api.example.com")
+	api.Group("/v1").Pre(tagFactory("api"))
+	api.GET("/")(handlerFactory("api"))
+
+	noodletest.Get("/users/").Serve(t, wk).Status(200).Body("[list]")
+	noodletest.Post("/users/1").Header("X-HTTP-Method-Override", "DELETE").Serve(t, wk).Status(200).Body("[overridden]")
+	noodletest.Post("/users/1").Serve(t, wk).Status(405)
+	noodletest.Get("http://legacy.example.com/users").Serve(t, wk).Status(301).Header("Location", "http://example.com/users")
+	noodletest.Get("/forbidden").Serve(t, wk).Status(403).Body("Forbidden\n")
+	noodletest.Get("http://www.example.com/").Serve(t, wk).Status(200).Header("X-Tag", "api").Body("[api]")
+	noodletest.Get("http://api.example.com/").Serve(t, wk).Header("X-Tag", "api")
+	noodletest.Get("http://example.com/").Serve(t, wk).Status(404).Header("X-Tag", "A")
+}
@@ -0,0 +1,125 @@
+package wok
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"net/http"
+	"strings"
+)
+
+// Indexer lists resource collection, routed as GET /resources
+type Indexer interface {
+	Index(context.Context, http.ResponseWriter, *http.Request) error
+}
+
+// Creator adds resource to collection, routed as POST /resources
+type Creator interface {
+	Create(context.Context, http.ResponseWriter, *http.Request) error
+}
+
+// Shower shows resource, routed as GET /resources/:resource_id
+type Shower interface {
+	Show(context.Context, http.ResponseWriter, *http.Request) error
+}
+
+// Updater replaces resource, routed as PUT /resources/:resource_id
+type Updater interface {
+	Update(context.Context, http.ResponseWriter, *http.Request) error
+}
+
+// Patcher modifies resource, routed as PATCH /resources/:resource_id
+type Patcher interface {
+	Patch(context.Context, http.ResponseWriter, *http.Request) error
+}
+
+// Destroyer deletes resource, routed as DELETE /resources/:resource_id
+type Destroyer interface {
+	Destroy(context.Context, http.ResponseWriter, *http.Request) error
+}
+
+// actionKey marks metadata of middlewares that apply to single resource action
+const actionKey = "wok.action"
+
+// actionMiddlewares are middlewares of the resource action
+type actionMiddlewares struct {
+	action string
+	mws    []noodle.Middleware
+}
+
+// Action wraps middlewares that apply only to the action of the resource.
+// Action is one of "index", "create", "show", "update", "patch" or "destroy".
+func Action(action string, mws ...noodle.Middleware) noodle.Middleware {
+	return noodle.Named("wok.Action", passthrough, actionKey, actionMiddlewares{action, mws})
+}
+
+// Resource registers RESTful routes for the methods implemented by controller,
+// see Indexer, Creator, Shower, Updater, Patcher and Destroyer interfaces.
+// Member routes identify resource with parameter named after the singular
+// form of the last path segment, e.g. "user_id" for "/users". Routes are named
+// after the path segment and action, e.g. "users.show". Middlewares apply to
+// all actions unless wrapped in Action. Resource returns the member route
+// group for registering nested resources, whose names are prefixed with the
+// parent resource name, e.g. "users.posts.index". Routes conflicting with
+// existing ones are not registered and are reported by Validate.
+func (wok *Wok) Resource(path string, ctrl interface{}, mws ...noodle.Middleware) *Wok {
+	var common []noodle.Middleware
+	actions := make(map[string][]noodle.Middleware)
+	for _, mw := range mws {
+		if am, ok := noodle.Describe(mw).Meta[actionKey].(actionMiddlewares); ok {
+			actions[am.action] = append(actions[am.action], am.mws...)
+			continue
+		}
+		common = append(common, mw)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	base := segments[len(segments)-1]
+	name := base
+	for router := wok; router != nil; router = router.parent {
+		if router.resource != "" {
+			name = router.resource + "." + base
+			break
+		}
+	}
+
+	collection := wok.Group(path, common...)
+	member := collection.Group("/:" + singular(base) + "_id")
+	member.resource = name
+	handle := func(router *Wok, method, action string, h noodle.Handler) {
+		// errors are recorded for Validate
+		_ = router.Handle(method, "/", append([]noodle.Middleware{Name(name + "." + action)}, actions[action]...)...)(h)
+	}
+	if c, ok := ctrl.(Indexer); ok {
+		handle(collection, "GET", "index", c.Index)
+	}
+	if c, ok := ctrl.(Creator); ok {
+		handle(collection, "POST", "create", c.Create)
+	}
+	if c, ok := ctrl.(Shower); ok {
+		handle(member, "GET", "show", c.Show)
+	}
+	if c, ok := ctrl.(Updater); ok {
+		handle(member, "PUT", "update", c.Update)
+	}
+	if c, ok := ctrl.(Patcher); ok {
+		handle(member, "PATCH", "patch", c.Patch)
+	}
+	if c, ok := ctrl.(Destroyer); ok {
+		handle(member, "DELETE", "destroy", c.Destroy)
+	}
+	return member
+}
+
+// singular makes naive singular form of English noun
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "ss"):
+		return s
+	case strings.HasSuffix(s, "s"):
+		return s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package wok_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+)
+
+type users struct{}
+
+func (users) Index(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprint(w, "[index]")
+	return nil
+}
+
+func (users) Show(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprintf(w, "[show %s]", wok.Var(ctx, "user_id"))
+	return nil
+}
+
+func (users) Destroy(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprintf(w, "[destroy %s]", wok.Var(ctx, "user_id"))
+	return nil
+}
+
+type posts struct{}
+
+func (posts) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprintf(w, "[create %s]", wok.Var(ctx, "user_id"))
+	return nil
+}
+
+func (posts) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprintf(w, "[update %s %s]", wok.Var(ctx, "user_id"), wok.Var(ctx, "post_id"))
+	return nil
+}
+
+func (posts) Patch(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	fmt.Fprintf(w, "[patch %s %s]", wok.Var(ctx, "user_id"), wok.Var(ctx, "post_id"))
+	return nil
+}
+
+func TestResource(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(mwFactory("A"))
+	u := wk.Group("/api").Resource("/users", users{}, mwFactory("U"), wok.Action("destroy", mwFactory("D")))
+	u.Resource("/posts", posts{}, wok.Action("create", mwFactory("C")))
+
+	noodletest.Get("/api/users").Serve(t, wk).Body("A>U>[index]")
+	noodletest.Get("/api/users/1").Serve(t, wk).Body("A>U>[show 1]")
+	noodletest.Delete("/api/users/1").Serve(t, wk).Body("A>U>D>[destroy 1]")
+	noodletest.Post("/api/users").Serve(t, wk).Body("A>U>").Header("Allow", "GET, OPTIONS")
+	noodletest.Post("/api/users/1/posts").Serve(t, wk).Body("A>U>C>[create 1]")
+	noodletest.Put("/api/users/1/posts/2").Serve(t, wk).Body("A>U>[update 1 2]")
+	noodletest.Patch("/api/users/1/posts/2").Serve(t, wk).Body("A>U>[patch 1 2]")
+
+	var names []string
+	for _, r := range wk.Routes() {
+		names = append(names, r.Method+" "+r.Path+" "+r.Name)
+	}
+	is.Equal(names, []string{
+		"GET /api/users users.index",
+		"GET /api/users/:user_id users.show",
+		"DELETE /api/users/:user_id users.destroy",
+		"POST /api/users/:user_id/posts users.posts.create",
+		"PUT /api/users/:user_id/posts/:post_id users.posts.update",
+		"PATCH /api/users/:user_id/posts/:post_id users.posts.patch",
+	})
+	url, err := wk.URL("users.posts.update", "user_id", "1", "post_id", "2")
+	is.NotErr(err)
+	is.Equal(url, "/api/users/1/posts/2")
+}
+
+func TestResourceConflict(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/users/:id")(handlerFactory("user"))
+	wk.Resource("/users", users{})
+	noodletest.Get("/users").Serve(t, wk).Body("[index]")
+	noodletest.Get("/users/1").Serve(t, wk).Body("[user]")
+	is.True(errors.Is(wk.Validate(), wok.ErrConflict))
+}
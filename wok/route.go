@@ -0,0 +1,157 @@
+package wok
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Route describes registered route
+type Route struct {
+	Method      string                 `json:"method"`
+	Host        string                 `json:"host,omitempty"`        // host pattern of enclosing host group
+	Path        string                 `json:"path"`                  // full path including group prefixes
+	Constraints map[string]string      `json:"constraints,omitempty"` // parameter constraints by name
+	Name        string                 `json:"name,omitempty"`
+	Groups      []string               `json:"groups,omitempty"` // prefixes of enclosing groups, outermost first
+	Tags        []string               `json:"tags,omitempty"`
+	Meta        map[string]interface{} `json:"meta,omitempty"` // arbitrary metadata set by Meta option
+	Middlewares []noodle.Info          `json:"middlewares"`
+	Handler     string                 `json:"handler"` // handler function name
+	Operation   *Operation             `json:"-"`       // OpenAPI metadata
+	constraints []constraint
+	badPattern  error // malformed constraint, reported on registration
+}
+
+// optionKey marks metadata of middlewares that carry route options
+const optionKey = "wok.option"
+
+// routeOption modifies route before it's registered
+type routeOption func(*Route)
+
+// passthrough is a middleware that does nothing
+func passthrough(next noodle.Handler) noodle.Handler {
+	return next
+}
+
+// option creates middleware carrying route option. Such middlewares are
+// removed from the chain by Handle.
+func option(name string, f routeOption) noodle.Middleware {
+	return noodle.Named(name, passthrough, optionKey, f)
+}
+
+// Name is a route option that assigns name to the route for URL reversal.
+// Routes named after existing ones are not registered, ErrDuplicateName is
+// returned instead.
+func Name(name string) noodle.Middleware {
+	return option("wok.Name", func(r *Route) {
+		r.Name = name
+	})
+}
+
+// Tags is a route option that adds tags to the route. Tags are also used in
+// OpenAPI documents if the operation has none.
+func Tags(tags ...string) noodle.Middleware {
+	return option("wok.Tags", func(r *Route) {
+		r.Tags = append(r.Tags, tags...)
+	})
+}
+
+// Meta is a route option that sets arbitrary route metadata
+func Meta(key string, value interface{}) noodle.Middleware {
+	return option("wok.Meta", func(r *Route) {
+		if r.Meta == nil {
+			r.Meta = make(map[string]interface{})
+		}
+		r.Meta[key] = value
+	})
+}
+
+// CurrentRoute returns route matched by the request. Returned route must not
+// be modified. False is returned outside of route handlers, e.g. for
+// NotFound handler.
+func CurrentRoute(c context.Context) (*Route, bool) {
+	res, ok := c.Value(routeKey).(*Route)
+	return res, ok
+}
+
+// applyOptions applies route options found among middlewares and returns the rest
+func applyOptions(r *Route, mws []noodle.Middleware) []noodle.Middleware {
+	res := make([]noodle.Middleware, 0, len(mws))
+	for _, mw := range mws {
+		if f, ok := noodle.Describe(mw).Meta[optionKey].(routeOption); ok {
+			f(r)
+			continue
+		}
+		res = append(res, mw)
+	}
+	return res
+}
+
+// routeTable keeps registered routes, shared by router and its groups
+type routeTable struct {
+	mu     sync.RWMutex
+	routes []*Route
+	names  map[string]*Route
+	groups []*Wok // root router and all groups
+	// hosts is replaced as a whole, so that requests read it without mutex
+	hosts atomic.Pointer[hostTable]
+	dyn   dynamicState
+	// problems found during registration, reported by Validate
+	problems   []*RouteError
+	pending    []*Route // routes waiting for handler
+	unresolved []string // names not found by URL
+	frozen     bool
+	// fallbackGen changes when fallback handlers or tracers are set, so that
+	// cached fallback chains are rebuilt
+	fallbackGen atomic.Uint64
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{names: make(map[string]*Route)}
+}
+
+// add registers route, its name must not be taken. Must be called with mutex
+// locked.
+func (t *routeTable) add(r *Route) {
+	if r.Name != "" {
+		t.names[r.Name] = r
+	}
+	t.routes = append(t.routes, r)
+}
+
+// addGroup registers group for fallback dispatching
+func (t *routeTable) addGroup(g *Wok) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.groups = append(t.groups, g)
+}
+
+// all returns copies of registered routes in order of registration
+func (t *routeTable) all() Routes {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res := make(Routes, len(t.routes))
+	for i, r := range t.routes {
+		res[i] = *r
+	}
+	return res
+}
+
+// byName looks up route by name
+func (t *routeTable) byName(name string) (*Route, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	r, ok := t.names[name]
+	return r, ok
+}
+
+// funcName returns short name of the function
+func funcName(f interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
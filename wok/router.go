@@ -0,0 +1,487 @@
+package wok
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrDuplicate is reported when the same method and path are registered twice
+	ErrDuplicate = errors.New("route is already registered")
+	// ErrConflict is reported when route parameter conflicts with parameter
+	// of the same segment in another route
+	ErrConflict = errors.New("route conflicts with existing path")
+	// ErrBadPattern is reported for malformed route paths
+	ErrBadPattern = errors.New("malformed route path")
+)
+
+// RouteError describes problem with the route. Method and path are empty for
+// problems with route names and name is empty for problems with paths.
+type RouteError struct {
+	Method string
+	Path   string
+	Name   string
+	Err    error
+}
+
+func (e *RouteError) Error() string {
+	res := "wok: "
+	if e.Method != "" {
+		res += e.Method + " "
+	}
+	if e.Path != "" {
+		res += e.Path + ": "
+	}
+	if e.Name != "" {
+		res += "name '" + e.Name + "': "
+	}
+	return res + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// Handle is a function that handles requests matched by Router. Params are
+// reused by Router after the function returns, so they must be copied to be
+// kept.
+type Handle func(http.ResponseWriter, *http.Request, Params)
+
+// Param is a single route parameter
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params are route parameters in order of appearance in the path
+type Params []Param
+
+// ByName returns value of the first parameter with the name or empty string
+func (ps Params) ByName(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// Router dispatches requests to handles by method and path. Path segments
+// are matched by static text first, then by named parameter such as `:id`,
+// then by catch-all parameter such as `*path`, backtracking when the rest of
+// the path doesn't match. Catch-all value starts with slash. Settings are
+// compatible with httprouter.
+type Router struct {
+	// RedirectTrailingSlash redirects to the path with trailing slash added
+	// or removed if only such route exists
+	RedirectTrailingSlash bool
+	// RedirectFixedPath redirects to the cleaned path matched case-insensitively
+	RedirectFixedPath bool
+	// HandleMethodNotAllowed answers requests for paths registered with other
+	// methods by MethodNotAllowed handler
+	HandleMethodNotAllowed bool
+	// HandleOPTIONS answers OPTIONS requests automatically with Allow header
+	HandleOPTIONS bool
+	// GlobalOPTIONS is called for automatic OPTIONS requests
+	GlobalOPTIONS http.Handler
+	// NotFound is called when no route matches
+	NotFound http.Handler
+	// MethodNotAllowed is called when route matches with another method
+	MethodNotAllowed http.Handler
+	// PanicHandler recovers panics of handles
+	PanicHandler func(http.ResponseWriter, *http.Request, interface{})
+
+	trees     map[string]*node
+	maxParams int
+	params    sync.Pool
+}
+
+// node is a tree node matching single path segment
+type node struct {
+	pattern  string  // route path up to and including the segment
+	label    string  // text of static segment
+	name     string  // parameter name of param and catch-all nodes
+	static   []*node // children matching segment text
+	param    *node   // child matching any non-empty segment
+	catchAll *node   // child matching the rest of the path
+	handle   Handle
+}
+
+// NewRouter creates Router with redirects and automatic OPTIONS and Method Not
+// Allowed responses enabled
+func NewRouter() *Router {
+	return &Router{
+		RedirectTrailingSlash:  true,
+		RedirectFixedPath:      true,
+		HandleMethodNotAllowed: true,
+		HandleOPTIONS:          true,
+	}
+}
+
+// Handle registers handle for the method and path. Malformed paths, duplicate
+// routes and parameters named differently from those of other routes at the
+// same position are reported as errors, leaving the router intact.
+func (r *Router) Handle(method, path string, h Handle) error {
+	if err := r.check(method, path); err != nil {
+		return err
+	}
+	return r.add(method, path, h, false)
+}
+
+// check reports error that Handle would return for the method and path
+func (r *Router) check(method, path string) error {
+	if err := r.add(method, path, nil, true); err != nil {
+		return &RouteError{Method: method, Path: path, Err: err}
+	}
+	return nil
+}
+
+// add inserts handle into the tree of the method. Dry run only checks that
+// the route can be inserted.
+func (r *Router) add(method, path string, h Handle, dry bool) error {
+	if path == "" || path[0] != '/' {
+		return fmt.Errorf("%w: path must begin with '/'", ErrBadPattern)
+	}
+	segments := strings.Split(path[1:], "/")
+	params := 0
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*"):
+			if len(s) == 1 || strings.ContainsAny(s[1:], ":*") {
+				return fmt.Errorf("%w: invalid parameter '%s'", ErrBadPattern, s)
+			}
+			if s[0] == '*' && i != len(segments)-1 {
+				return fmt.Errorf("%w: catch-all parameter '%s' must be the last segment", ErrBadPattern, s)
+			}
+			params++
+		case strings.ContainsAny(s, ":*"):
+			return fmt.Errorf("%w: parameter must occupy whole segment '%s'", ErrBadPattern, s)
+		}
+	}
+	n := r.trees[method]
+	if n == nil {
+		if dry {
+			return nil
+		}
+		if r.trees == nil {
+			r.trees = make(map[string]*node)
+		}
+		n = new(node)
+		r.trees[method] = n
+	}
+	for _, s := range segments {
+		next, err := n.child(s)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			if dry {
+				return nil
+			}
+			next = n.insert(s)
+		}
+		n = next
+	}
+	if n.handle != nil {
+		return ErrDuplicate
+	}
+	if dry {
+		return nil
+	}
+	n.handle = h
+	if params > r.maxParams {
+		r.maxParams = params
+	}
+	return nil
+}
+
+// child returns existing child for the segment pattern, or error if the
+// parameter is named differently
+func (n *node) child(s string) (*node, error) {
+	var res *node
+	switch {
+	case strings.HasPrefix(s, ":"):
+		res = n.param
+	case strings.HasPrefix(s, "*"):
+		res = n.catchAll
+	default:
+		for _, c := range n.static {
+			if c.label == s {
+				return c, nil
+			}
+		}
+		return nil, nil
+	}
+	if res != nil && res.name != s[1:] {
+		return nil, fmt.Errorf("%w %s", ErrConflict, res.pattern)
+	}
+	return res, nil
+}
+
+// insert adds child for the segment pattern
+func (n *node) insert(s string) *node {
+	res := &node{pattern: n.pattern + "/" + s}
+	switch {
+	case strings.HasPrefix(s, ":"):
+		res.name = s[1:]
+		n.param = res
+	case strings.HasPrefix(s, "*"):
+		res.name = s[1:]
+		n.catchAll = res
+	default:
+		res.label = s
+		n.static = append(n.static, res)
+	}
+	return res
+}
+
+// search matches the path starting from segment at pos. Parameters are
+// appended to ps unless it's nil.
+func (n *node) search(path string, pos int, ps *Params) Handle {
+	end := strings.IndexByte(path[pos:], '/')
+	last := end < 0
+	if last {
+		end = len(path)
+	} else {
+		end += pos
+	}
+	seg := path[pos:end]
+	for _, c := range n.static {
+		if c.label != seg {
+			continue
+		}
+		if h := c.match(path, end, last, ps); h != nil {
+			return h
+		}
+		break
+	}
+	if c := n.param; c != nil && seg != "" {
+		var mark int
+		if ps != nil {
+			mark = len(*ps)
+			*ps = append(*ps, Param{Key: c.name, Value: seg})
+		}
+		if h := c.match(path, end, last, ps); h != nil {
+			return h
+		}
+		if ps != nil {
+			*ps = (*ps)[:mark]
+		}
+	}
+	if c := n.catchAll; c != nil && c.handle != nil {
+		if ps != nil {
+			*ps = append(*ps, Param{Key: c.name, Value: path[pos-1:]})
+		}
+		return c.handle
+	}
+	return nil
+}
+
+// match returns handle of the node if the path ends with its segment, or
+// continues search below the node
+func (n *node) match(path string, end int, last bool, ps *Params) Handle {
+	if last {
+		return n.handle
+	}
+	return n.search(path, end+1, ps)
+}
+
+// fold matches the path starting from segment at pos case-insensitively and
+// returns path with static segments spelled as registered
+func (n *node) fold(path string, pos int, buf []byte) ([]byte, bool) {
+	end := strings.IndexByte(path[pos:], '/')
+	last := end < 0
+	if last {
+		end = len(path)
+	} else {
+		end += pos
+	}
+	seg := path[pos:end]
+	try := func(c *node, text string) ([]byte, bool) {
+		res := append(append(buf, '/'), text...)
+		if last {
+			return res, c.handle != nil
+		}
+		return c.fold(path, end+1, res)
+	}
+	for _, c := range n.static {
+		if strings.EqualFold(c.label, seg) {
+			if res, ok := try(c, c.label); ok {
+				return res, true
+			}
+		}
+	}
+	if n.param != nil && seg != "" {
+		if res, ok := try(n.param, seg); ok {
+			return res, true
+		}
+	}
+	if n.catchAll != nil && n.catchAll.handle != nil {
+		return append(buf, path[pos-1:]...), true
+	}
+	return nil, false
+}
+
+// getParams returns pooled parameter buffer
+func (r *Router) getParams() *Params {
+	if ps, _ := r.params.Get().(*Params); ps != nil && cap(*ps) >= r.maxParams {
+		*ps = (*ps)[:0]
+		return ps
+	}
+	ps := make(Params, 0, r.maxParams)
+	return &ps
+}
+
+// putParams returns parameter buffer to the pool
+func (r *Router) putParams(ps *Params) {
+	r.params.Put(ps)
+}
+
+// Lookup finds handle for the method and path. If no handle was found, it
+// reports whether the path with trailing slash added or removed would match.
+func (r *Router) Lookup(method, path string) (Handle, Params, bool) {
+	root := r.trees[method]
+	if root == nil || path == "" || path[0] != '/' {
+		return nil, nil, false
+	}
+	var ps Params
+	if h := root.search(path, 1, &ps); h != nil {
+		return h, ps, false
+	}
+	return nil, nil, root.redirectable(path)
+}
+
+// redirectable reports whether the path with trailing slash added or removed
+// has a handle
+func (n *node) redirectable(path string) bool {
+	if path == "/" {
+		return false
+	}
+	if strings.HasSuffix(path, "/") {
+		return n.search(path[:len(path)-1], 1, nil) != nil
+	}
+	return n.search(path+"/", 1, nil) != nil
+}
+
+// allowed lists methods with handles for the path, OPTIONS included
+func (r *Router) allowed(path, reqMethod string) string {
+	var res []string
+	for method, root := range r.trees {
+		if method == reqMethod || method == http.MethodOptions {
+			continue
+		}
+		if path == "*" || root.search(path, 1, nil) != nil {
+			res = append(res, method)
+		}
+	}
+	if len(res) == 0 {
+		return ""
+	}
+	res = append(res, http.MethodOptions)
+	for i := 1; i < len(res); i++ {
+		for j := i; j > 0 && res[j] < res[j-1]; j-- {
+			res[j], res[j-1] = res[j-1], res[j]
+		}
+	}
+	return strings.Join(res, ", ")
+}
+
+// ServeHTTP dispatches request to the matching handle. Parameters passed to
+// the handle are reused after it returns and must not be retained.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.PanicHandler != nil {
+		defer func() {
+			if p := recover(); p != nil {
+				r.PanicHandler(w, req, p)
+			}
+		}()
+	}
+	path := req.URL.Path
+	if root := r.trees[req.Method]; root != nil && path != "" && path[0] == '/' {
+		ps := r.getParams()
+		if h := root.search(path, 1, ps); h != nil {
+			defer r.putParams(ps)
+			h(w, req, *ps)
+			return
+		}
+		r.putParams(ps)
+		if req.Method != http.MethodConnect && path != "/" && r.redirect(w, req, root) {
+			return
+		}
+	}
+	if req.Method == http.MethodOptions && r.HandleOPTIONS {
+		if allow := r.allowed(path, http.MethodOptions); allow != "" {
+			w.Header().Set("Allow", allow)
+			if r.GlobalOPTIONS != nil {
+				r.GlobalOPTIONS.ServeHTTP(w, req)
+			}
+			return
+		}
+	} else if r.HandleMethodNotAllowed {
+		if allow := r.allowed(path, req.Method); allow != "" {
+			w.Header().Set("Allow", allow)
+			if r.MethodNotAllowed != nil {
+				r.MethodNotAllowed.ServeHTTP(w, req)
+			} else {
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			}
+			return
+		}
+	}
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(w, req)
+	} else {
+		http.NotFound(w, req)
+	}
+}
+
+// redirect sends redirect to the path with fixed trailing slash or case and
+// reports whether it was sent
+func (r *Router) redirect(w http.ResponseWriter, req *http.Request, root *node) bool {
+	code := http.StatusMovedPermanently
+	if req.Method != http.MethodGet {
+		code = http.StatusTemporaryRedirect
+	}
+	path := req.URL.Path
+	if r.RedirectTrailingSlash && root.redirectable(path) {
+		if strings.HasSuffix(path, "/") {
+			req.URL.Path = path[:len(path)-1]
+		} else {
+			req.URL.Path = path + "/"
+		}
+		http.Redirect(w, req, req.URL.String(), code)
+		return true
+	}
+	if !r.RedirectFixedPath {
+		return false
+	}
+	clean := cleanPath(path)
+	fixed, ok := root.fold(clean, 1, make([]byte, 0, len(clean)+1))
+	if !ok && r.RedirectTrailingSlash && clean != "/" {
+		if strings.HasSuffix(clean, "/") {
+			fixed, ok = root.fold(clean[:len(clean)-1], 1, fixed[:0])
+		} else {
+			fixed, ok = root.fold(clean+"/", 1, fixed[:0])
+		}
+	}
+	if !ok || string(fixed) == path {
+		return false
+	}
+	req.URL.Path = string(fixed)
+	http.Redirect(w, req, req.URL.String(), code)
+	return true
+}
+
+// cleanPath removes dot segments and repeated slashes, keeping trailing slash
+func cleanPath(p string) string {
+	res := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && res != "/" {
+		res += "/"
+	}
+	return res
+}
@@ -0,0 +1,215 @@
+package wok_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"github.com/julienschmidt/httprouter"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func echoParams(tag string) wok.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps wok.Params) {
+		fmt.Fprintf(w, "%s%v", tag, ps)
+	}
+}
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestRouterPriority(t *testing.T) {
+	is := is.New(t)
+	r := wok.NewRouter()
+	for _, path := range []string{"/", "/users/new", "/users/:id", "/users/:id/edit", "/users/*rest", "/*any", "/files/", "/files/*path"} {
+		is.NotErr(r.Handle("GET", path, echoParams(path)))
+	}
+	for path, expected := range map[string]string{
+		"/":                 "/[]",
+		"/users/new":        "/users/new[]",
+		"/users/12":         "/users/:id[{id 12}]",
+		"/users/new/edit":   "/users/:id/edit[{id new}]",
+		"/users/12/edit/x":  "/users/*rest[{rest /12/edit/x}]",
+		"/users/":           "/users/*rest[{rest /}]",
+		"/other/path":       "/*any[{any /other/path}]",
+		"/files/":           "/files/[]",
+		"/files/a/b.txt":    "/files/*path[{path /a/b.txt}]",
+		"/users/new/edit/":  "/users/*rest[{rest /new/edit/}]",
+		"/users/new/delete": "/users/*rest[{rest /new/delete}]",
+	} {
+		is.Equal(serve(r, "GET", path).Body.String(), expected)
+	}
+
+	h, ps, tsr := r.Lookup("GET", "/users/5/edit")
+	is.NotNil(h)
+	is.Equal(ps.ByName("id"), "5")
+	is.False(tsr)
+	h, _, tsr = r.Lookup("POST", "/users/5")
+	is.Nil(h)
+	is.False(tsr)
+}
+
+func TestRouterErrors(t *testing.T) {
+	is := is.New(t)
+	r := wok.NewRouter()
+	is.NotErr(r.Handle("GET", "/users/:id", echoParams("")))
+	is.NotErr(r.Handle("POST", "/users/:name", echoParams("")))
+
+	err := r.Handle("GET", "/users/:id", echoParams(""))
+	is.True(errors.Is(err, wok.ErrDuplicate))
+	is.Equal(err.Error(), "wok: GET /users/:id: route is already registered")
+	err = r.Handle("GET", "/users/:name/posts", echoParams(""))
+	is.True(errors.Is(err, wok.ErrConflict))
+	is.Equal(err.Error(), "wok: GET /users/:name/posts: route conflicts with existing path /users/:id")
+	for _, path := range []string{"users", "/files/*path/x", "/users/:", "/user_:id", "/a/b*"} {
+		is.True(errors.Is(r.Handle("GET", path, echoParams("")), wok.ErrBadPattern))
+	}
+	// failed registration leaves no traces
+	is.Equal(serve(r, "GET", "/users/:name/posts").Code, 404)
+	is.NotErr(r.Handle("GET", "/users/:id/posts", echoParams("")))
+}
+
+func TestRouterRedirects(t *testing.T) {
+	is := is.New(t)
+	r := wok.NewRouter()
+	is.NotErr(r.Handle("GET", "/users/new", echoParams("")))
+	is.NotErr(r.Handle("POST", "/users/new", echoParams("")))
+	is.NotErr(r.Handle("GET", "/docs/", echoParams("")))
+	is.NotErr(r.Handle("GET", "/Files/:name", echoParams("")))
+
+	for path, location := range map[string]string{
+		"/users/new/":         "/users/new",
+		"/docs":               "/docs/",
+		"/USERS/New":          "/users/new",
+		"/users/../users/new": "/users/new",
+		"//users/new/":        "/users/new",
+		"/files/ReadMe.md":    "/Files/ReadMe.md",
+		"/users/new/?page=2":  "/users/new?page=2",
+	} {
+		w := serve(r, "GET", path)
+		is.Equal(w.Code, 301)
+		is.Equal(w.Header().Get("Location"), location)
+	}
+	w := serve(r, "POST", "/users/new/")
+	is.Equal(w.Code, 307)
+	is.Equal(w.Header().Get("Location"), "/users/new")
+
+	r.RedirectTrailingSlash = false
+	r.RedirectFixedPath = false
+	is.Equal(serve(r, "GET", "/docs").Code, 404)
+	is.Equal(serve(r, "GET", "/USERS/new").Code, 404)
+}
+
+func TestRouterFallbacks(t *testing.T) {
+	is := is.New(t)
+	r := wok.NewRouter()
+	is.NotErr(r.Handle("GET", "/users/:id", echoParams("")))
+	is.NotErr(r.Handle("PUT", "/users/:id", echoParams("")))
+	is.NotErr(r.Handle("POST", "/users", echoParams("")))
+
+	w := serve(r, "DELETE", "/users/1")
+	is.Equal(w.Code, 405)
+	is.Equal(w.Header().Get("Allow"), "GET, OPTIONS, PUT")
+	w = serve(r, "OPTIONS", "/users/1")
+	is.Equal(w.Code, 200)
+	is.Equal(w.Header().Get("Allow"), "GET, OPTIONS, PUT")
+	w = serve(r, "OPTIONS", "*")
+	is.Equal(w.Header().Get("Allow"), "GET, OPTIONS, POST, PUT")
+	is.Equal(serve(r, "GET", "/posts").Code, 404)
+
+	r.HandleMethodNotAllowed = false
+	is.Equal(serve(r, "DELETE", "/users/1").Code, 404)
+
+	r.PanicHandler = func(w http.ResponseWriter, r *http.Request, p interface{}) {
+		http.Error(w, fmt.Sprint(p), 500)
+	}
+	is.NotErr(r.Handle("GET", "/panic", func(http.ResponseWriter, *http.Request, wok.Params) {
+		panic("boom")
+	}))
+	w = serve(r, "GET", "/panic")
+	is.Equal(w.Code, 500)
+	is.Equal(w.Body.String(), "boom\n")
+}
+
+func TestRouterAllocs(t *testing.T) {
+	is := is.New(t)
+	r := wok.NewRouter()
+	var id string
+	is.NotErr(r.Handle("GET", "/users/new", func(http.ResponseWriter, *http.Request, wok.Params) {}))
+	is.NotErr(r.Handle("GET", "/users/:id/posts/:post", func(w http.ResponseWriter, r *http.Request, ps wok.Params) {
+		id = ps.ByName("id")
+	}))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/12/posts/1", nil)
+	is.Equal(testing.AllocsPerRun(100, func() { r.ServeHTTP(w, req) }), 0.0)
+	is.Equal(id, "12")
+}
+
+func TestStaticOverParam(t *testing.T) {
+	wk := wok.New()
+	wk.GET("/users/new")(handlerFactory("new"))
+	wk.GET("/users/:id")(handlerFactory("show"))
+	wk.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[mounted "+r.URL.Path+"]")
+	}))
+
+	noodletest.Get("/users/new").Serve(t, wk).Body("[new]")
+	noodletest.Get("/users/12").Serve(t, wk).Body("[show]")
+	noodletest.Get("/about").Serve(t, wk).Body("[mounted /about]")
+}
+
+var benchRoutes = []string{
+	"/",
+	"/users",
+	"/users/:id",
+	"/users/:id/posts",
+	"/users/:id/posts/:post",
+	"/posts",
+	"/posts/:post/comments",
+	"/static/*path",
+}
+
+var benchPaths = []string{
+	"/",
+	"/users/12",
+	"/users/12/posts/345",
+	"/posts/1/comments",
+	"/static/css/site.css",
+}
+
+func BenchmarkRouter(b *testing.B) {
+	r := wok.NewRouter()
+	for _, path := range benchRoutes {
+		r.Handle("GET", path, func(http.ResponseWriter, *http.Request, wok.Params) {})
+	}
+	benchmarkRouter(b, r)
+}
+
+func BenchmarkHTTPRouter(b *testing.B) {
+	r := httprouter.New()
+	for _, path := range benchRoutes {
+		r.Handle("GET", path, func(http.ResponseWriter, *http.Request, httprouter.Params) {})
+	}
+	benchmarkRouter(b, r)
+}
+
+func benchmarkRouter(b *testing.B, h http.Handler) {
+	w := httptest.NewRecorder()
+	reqs := make([]*http.Request, len(benchPaths))
+	for i, path := range benchPaths {
+		reqs[i] = httptest.NewRequest("GET", path, nil)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, req := range reqs {
+			h.ServeHTTP(w, req)
+		}
+	}
+}
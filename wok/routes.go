@@ -0,0 +1,67 @@
+package wok
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Routes is a list of registered routes
+type Routes []Route
+
+// Routes lists all routes registered on router and its groups in order of registration
+func (wok *Wok) Routes() Routes {
+	return wok.routes.all()
+}
+
+// WriteText writes routes as a text table
+func (rs Routes) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tMIDDLEWARES\tHANDLER")
+	for _, r := range rs {
+		names := make([]string, len(r.Middlewares))
+		for i, mw := range r.Middlewares {
+			names[i] = mw.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Host+r.Path, r.Name, strings.Join(names, ","), r.Handler)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes routes as indented JSON array
+func (rs Routes) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rs)
+}
+
+// WriteDOT writes routes as Graphviz digraph where routes are attached to the
+// nodes of their groups
+func (rs Routes) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph routes {\n\trankdir=LR;\n\tnode [shape=box];\n\t\"/\";\n")
+	groups := map[string]bool{"/": true}
+	for i, r := range rs {
+		parent := "/"
+		for _, prefix := range r.Groups {
+			group := UrlJoin(parent, prefix)
+			if !groups[group] {
+				groups[group] = true
+				fmt.Fprintf(&b, "\t%s;\n\t%s -> %s;\n", strconv.Quote(group), strconv.Quote(parent), strconv.Quote(group))
+			}
+			parent = group
+		}
+		label := r.Method + " " + r.Host + r.Path
+		if r.Name != "" {
+			label += "\n" + r.Name
+		}
+		node := strconv.Quote("route" + strconv.Itoa(i))
+		fmt.Fprintf(&b, "\t%s [shape=ellipse, label=%s];\n\t%s -> %s;\n", node, strconv.Quote(label), strconv.Quote(parent), node)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
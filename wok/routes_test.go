@@ -0,0 +1,109 @@
+package wok_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/render"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func listItems(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func routesFixture() *wok.Wok {
+	wk := wok.New(noodle.Named("A", mwFactory("A")))
+	wk.GET("/", wok.Name("index"))(handlerFactory("I"))
+	api := wk.Group("/api", render.JSON)
+	api.GET("/items", wok.Name("items"))(listItems)
+	api.Group("/v2").POST("/items")(listItems)
+	return wk
+}
+
+func TestRoutes(t *testing.T) {
+	is := is.New(t)
+	routes := routesFixture().Routes()
+	is.Equal(len(routes), 3)
+
+	is.Equal(routes[0].Method, "GET")
+	is.Equal(routes[0].Path, "/")
+	is.Equal(routes[0].Name, "index")
+	is.Equal(len(routes[0].Groups), 0)
+	is.Equal(routes[0].Handler, "wok_test.handlerFactory.func1")
+
+	is.Equal(routes[1].Path, "/api/items")
+	is.Equal(routes[1].Groups, []string{"/api"})
+	is.Equal(routes[1].Handler, "wok_test.listItems")
+	is.Equal(len(routes[1].Middlewares), 2)
+	is.Equal(routes[1].Middlewares[0].Name, "A")
+	is.Equal(routes[1].Middlewares[1].Name, "render.JSON")
+
+	is.Equal(routes[2].Method, "POST")
+	is.Equal(routes[2].Path, "/api/v2/items")
+	is.Equal(routes[2].Groups, []string{"/api", "/v2"})
+}
+
+func TestRoutesExport(t *testing.T) {
+	is := is.New(t)
+	routes := routesFixture().Routes()
+
+	buf := new(bytes.Buffer)
+	is.NotErr(routes.WriteText(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	is.Equal(len(lines), 4)
+	is.Equal(strings.Fields(lines[0]), []string{"METHOD", "PATH", "NAME", "MIDDLEWARES", "HANDLER"})
+	is.Equal(strings.Fields(lines[2]), []string{"GET", "/api/items", "items", "A,render.JSON", "wok_test.listItems"})
+
+	buf.Reset()
+	is.NotErr(routes.WriteJSON(buf))
+	var decoded []map[string]interface{}
+	is.NotErr(json.Unmarshal(buf.Bytes(), &decoded))
+	is.Equal(len(decoded), 3)
+	is.Equal(decoded[1]["path"], "/api/items")
+	is.Equal(decoded[1]["name"], "items")
+
+	buf.Reset()
+	is.NotErr(routes.WriteDOT(buf))
+	dot := buf.String()
+	is.True(strings.HasPrefix(dot, "digraph routes {"))
+	is.True(strings.Contains(dot, `"/" -> "/api";`))
+	is.True(strings.Contains(dot, `"/api" -> "/api/v2";`))
+	is.True(strings.Contains(dot, `"route1" [shape=ellipse, label="GET /api/items\nitems"];`))
+	is.True(strings.Contains(dot, `"/api/v2" -> "route2";`))
+}
+
+func TestCurrentRoute(t *testing.T) {
+	is := is.New(t)
+	metrics := func(next noodle.Handler) noodle.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			route, ok := wok.CurrentRoute(ctx)
+			if !ok {
+				fmt.Fprint(w, "<none>")
+				return next(ctx, w, r)
+			}
+			fmt.Fprintf(w, "<%s %s %s %v %v>", route.Method, route.Path, route.Name, route.Tags, route.Meta["limit"])
+			return next(ctx, w, r)
+		}
+	}
+	wk := wok.New(metrics)
+	wk.Group("/api").GET("/users/:id<int>", wok.Name("user"), wok.Tags("users", "public"), wok.Meta("limit", 10))(handlerFactory("user"))
+
+	noodletest.Get("/api/users/12").Serve(t, wk).Body("<GET /api/users/:id user [users public] 10>[user]")
+	noodletest.Get("/missing").Serve(t, wk).Body("<none>")
+
+	route := wk.Routes()[0]
+	is.Equal(route.Tags, []string{"users", "public"})
+	is.Equal(route.Meta, map[string]interface{}{"limit": 10})
+	is.Equal(route.Middlewares[0].Name, "wok_test.TestCurrentRoute.func1")
+	doc, err := wk.OpenAPI(wok.OpenAPIInfo{})
+	is.NotErr(err)
+	is.Equal(doc.Paths["/api/users/{id}"]["get"].Tags, []string{"users", "public"})
+}
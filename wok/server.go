@@ -0,0 +1,269 @@
+package wok
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultDrainTimeout limits graceful shutdown of the Server if DrainTimeout is not set
+const DefaultDrainTimeout = 30 * time.Second
+
+// NotReady is returned by Server.Readiness before server has started and after
+// shutdown has begun
+var NotReady = noodle.NewHTTPError(http.StatusServiceUnavailable, "not_ready", "Server is not ready")
+
+// Hook is called on server startup or shutdown
+type Hook func(ctx context.Context) error
+
+// Server runs Wok router with graceful shutdown. Zero values of the exported
+// fields are replaced with sensible defaults.
+type Server struct {
+	// Addr is the listen address: "host:port" for TCP, "unix:/path/to.sock"
+	// for Unix socket, "systemd" or "systemd:<index or name>" for a socket
+	// passed by systemd socket activation
+	Addr string
+	// DrainTimeout limits waiting for in-flight requests on shutdown. When
+	// it expires, root context is cancelled and remaining connections are
+	// closed.
+	DrainTimeout time.Duration
+	// ShutdownDelay is the pause between flipping readiness and draining,
+	// so that load balancers stop sending new requests
+	ShutdownDelay time.Duration
+	// Signals trigger graceful shutdown, SIGINT and SIGTERM by default
+	Signals []os.Signal
+	// Config provides timeouts, TLS and other settings of http.Server. Its
+	// Addr is ignored and Handler is replaced with the router.
+	Config *http.Server
+
+	wok        *Wok
+	ready      atomic.Bool
+	mu         sync.Mutex
+	onStart    []Hook
+	onShutdown []Hook
+	stop       chan struct{}
+	stopInit   sync.Once
+	stopOnce   sync.Once
+}
+
+// NewServer creates server for the router listening on addr
+func NewServer(wok *Wok, addr string) *Server {
+	return &Server{Addr: addr, wok: wok}
+}
+
+// stopped returns channel closed by Shutdown
+func (s *Server) stopped() chan struct{} {
+	s.stopInit.Do(func() {
+		s.stop = make(chan struct{})
+	})
+	return s.stop
+}
+
+// Run listens on addr and serves requests until SIGINT or SIGTERM is received,
+// then shuts down gracefully
+func (wok *Wok) Run(addr string) error {
+	return NewServer(wok, addr).Run(context.Background())
+}
+
+// OnStart registers hook that is called after the server starts listening
+// but before it accepts requests. Hooks are called in registration order; an
+// error aborts the startup.
+func (s *Server) OnStart(h Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStart = append(s.onStart, h)
+}
+
+// OnShutdown registers hook that is called after in-flight requests are
+// drained. Hooks are called in registration order, all errors are returned.
+func (s *Server) OnShutdown(h Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onShutdown = append(s.onShutdown, h)
+}
+
+// Ready reports whether the server accepts requests
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Readiness is a handler for readiness probes. It responds with 200 when the
+// server is ready and returns NotReady otherwise.
+func (s *Server) Readiness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return writeErrors(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if !s.Ready() {
+			return NotReady
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err := w.Write([]byte("ok\n"))
+		return err
+	})(ctx, w, r)
+}
+
+// Shutdown triggers graceful shutdown of the running server. It does not wait
+// for the shutdown to complete.
+func (s *Server) Shutdown() {
+	s.stopOnce.Do(func() {
+		close(s.stopped())
+	})
+}
+
+// Run listens on the configured address and serves requests until ctx is
+// done, one of the signals is received or Shutdown is called
+func (s *Server) Run(ctx context.Context) error {
+	l, err := Listen(s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve accepts requests on the listener until ctx is done, one of the signals
+// is received or Shutdown is called. On shutdown the server becomes not ready,
+// waits for ShutdownDelay, drains in-flight requests, cancels the root context
+// of the router and calls shutdown hooks. The root context is derived from
+// the one set by SetContext, which is restored when Serve returns.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	signals := s.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stopSignals := signal.NotifyContext(ctx, signals...)
+	defer stopSignals()
+
+	orig := s.wok.rootCtx.Load()
+	root := context.Background()
+	if orig != nil && *orig != nil {
+		root = *orig
+	}
+	rootCtx, cancel := context.WithCancelCause(root)
+	defer cancel(http.ErrServerClosed)
+	s.wok.SetContext(rootCtx)
+	defer s.wok.rootCtx.Store(orig)
+
+	srv := s.Config
+	if srv == nil {
+		srv = new(http.Server)
+	}
+	srv.Handler = s.wok
+
+	s.mu.Lock()
+	onStart, onShutdown := s.onStart, s.onShutdown
+	s.mu.Unlock()
+	for _, h := range onStart {
+		if err := h(ctx); err != nil {
+			l.Close()
+			return err
+		}
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			served <- srv.ServeTLS(l, "", "")
+			return
+		}
+		served <- srv.Serve(l)
+	}()
+	s.ready.Store(true)
+
+	var errs []error
+	select {
+	case err := <-served:
+		errs = append(errs, err)
+	case <-ctx.Done():
+	case <-s.stopped():
+	}
+	s.ready.Store(false)
+	if s.ShutdownDelay > 0 {
+		time.Sleep(s.ShutdownDelay)
+	}
+
+	drain := s.DrainTimeout
+	if drain <= 0 {
+		drain = DefaultDrainTimeout
+	}
+	drainCtx, stopDrain := context.WithTimeout(context.Background(), drain)
+	defer stopDrain()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		cancel(err)
+		errs = append(errs, err, srv.Close())
+	}
+	cancel(http.ErrServerClosed)
+	if len(errs) == 0 {
+		errs = append(errs, <-served)
+	}
+
+	hookCtx, stopHooks := context.WithTimeout(context.Background(), drain)
+	defer stopHooks()
+	for _, h := range onShutdown {
+		errs = append(errs, h(hookCtx))
+	}
+	for i, err := range errs {
+		if errors.Is(err, http.ErrServerClosed) {
+			errs[i] = nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Listen creates listener for the address in Server.Addr format
+func Listen(addr string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		path := strings.TrimPrefix(addr, "unix:")
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			// remove socket left by previous run
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	case addr == "systemd" || strings.HasPrefix(addr, "systemd:"):
+		return systemdListener(strings.TrimPrefix(strings.TrimPrefix(addr, "systemd"), ":"))
+	}
+	return net.Listen("tcp", addr)
+}
+
+// systemdListenFdsStart is the first file descriptor passed by systemd
+const systemdListenFdsStart = 3
+
+// systemdListener returns listener for the socket passed by systemd, selected
+// by index or name from LISTEN_FDNAMES
+func systemdListener(sel string) (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("wok: no sockets passed by systemd")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, errors.New("wok: no sockets passed by systemd")
+	}
+	idx := 0
+	if sel != "" {
+		if idx, err = strconv.Atoi(sel); err != nil {
+			idx = -1
+			for i, name := range strings.Split(os.Getenv("LISTEN_FDNAMES"), ":") {
+				if name == sel {
+					idx = i
+					break
+				}
+			}
+		}
+	}
+	if idx < 0 || idx >= count {
+		return nil, fmt.Errorf("wok: systemd socket %q not found", sel)
+	}
+	f := os.NewFile(uintptr(systemdListenFdsStart+idx), "systemd:"+strconv.Itoa(idx))
+	defer f.Close()
+	return net.FileListener(f)
+}
@@ -0,0 +1,192 @@
+package wok_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func get(client *http.Client, path string) (int, string, error) {
+	resp, err := client.Get("http://wok" + path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func waitReady(srv *wok.Server) {
+	for !srv.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServer(t *testing.T) {
+	is := is.New(t)
+	sock := filepath.Join(t.TempDir(), "wok.sock")
+	wk := wok.New()
+	srv := wok.NewServer(wk, "unix:"+sock)
+	started := make(chan struct{})
+	wk.GET("/ready")(srv.Readiness)
+	wk.GET("/slow")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		_, err := fmt.Fprint(w, "done")
+		return err
+	})
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	hook := func(name string) wok.Hook {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	srv.OnStart(hook("start1"))
+	srv.OnStart(hook("start2"))
+	srv.OnShutdown(hook("stop1"))
+	srv.OnShutdown(hook("stop2"))
+
+	res := make(chan error)
+	go func() {
+		res <- srv.Run(context.Background())
+	}()
+	waitReady(srv)
+	client := unixClient(sock)
+	status, body, err := get(client, "/ready")
+	is.NotErr(err)
+	is.Equal(status, 200)
+	is.Equal(body, "ok\n")
+
+	slow := make(chan string)
+	go func() {
+		_, body, _ := get(client, "/slow")
+		slow <- body
+	}()
+	<-started
+	srv.Shutdown()
+	is.Equal(<-slow, "done")
+	is.NotErr(<-res)
+	is.False(srv.Ready())
+	is.Equal(calls, []string{"start1", "start2", "stop1", "stop2"})
+	_, _, err = get(client, "/ready")
+	is.Err(err)
+}
+
+func TestServerDrainTimeout(t *testing.T) {
+	is := is.New(t)
+	l, err := wok.Listen("127.0.0.1:0")
+	is.NotErr(err)
+	wk := wok.New()
+	wk.SetContext(context.WithValue(context.Background(), "user", "value"))
+	srv := wok.NewServer(wk, "")
+	srv.DrainTimeout = 20 * time.Millisecond
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	wk.GET("/stream")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		is.Equal(ctx.Value("user"), "value")
+		close(started)
+		<-ctx.Done()
+		cancelled <- context.Cause(ctx)
+		return nil
+	})
+	res := make(chan error)
+	go func() {
+		res <- srv.Serve(context.Background(), l)
+	}()
+	waitReady(srv)
+	go http.Get("http://" + l.Addr().String() + "/stream")
+	<-started
+	srv.Shutdown()
+	is.True(errors.Is(<-res, context.DeadlineExceeded))
+	// handler is cancelled by the root context or by the closed connection,
+	// whichever comes first
+	cause := <-cancelled
+	is.True(cause == context.DeadlineExceeded || cause == context.Canceled)
+
+	// original root context is restored
+	wk.GET("/after")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		is.Equal(ctx.Value("user"), "value")
+		is.NotErr(ctx.Err())
+		return nil
+	})
+	wk.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/after", nil))
+}
+
+func TestServerLiteralShutdown(t *testing.T) {
+	srv := &wok.Server{Addr: "127.0.0.1:0"}
+	srv.Shutdown()
+	srv.Shutdown()
+}
+
+func TestServerSignal(t *testing.T) {
+	is := is.New(t)
+	l, err := wok.Listen("127.0.0.1:0")
+	is.NotErr(err)
+	srv := wok.NewServer(wok.New(), "")
+	srv.Signals = []os.Signal{syscall.SIGUSR1}
+	srv.OnShutdown(func(ctx context.Context) error {
+		return errors.New("hook failed")
+	})
+	res := make(chan error)
+	go func() {
+		res <- srv.Serve(context.Background(), l)
+	}()
+	waitReady(srv)
+	is.NotErr(syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	is.Equal((<-res).Error(), "hook failed")
+}
+
+func TestServerStartError(t *testing.T) {
+	is := is.New(t)
+	l, err := wok.Listen("127.0.0.1:0")
+	is.NotErr(err)
+	srv := wok.NewServer(wok.New(), "")
+	srv.OnStart(func(ctx context.Context) error {
+		return errors.New("no database")
+	})
+	is.Equal(srv.Serve(context.Background(), l).Error(), "no database")
+	is.False(srv.Ready())
+	_, err = net.Dial("tcp", l.Addr().String())
+	is.Err(err)
+}
+
+func TestListenSystemd(t *testing.T) {
+	is := is.New(t)
+	_, err := wok.Listen("systemd")
+	is.Equal(err.Error(), "wok: no sockets passed by systemd")
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "http")
+	_, err = wok.Listen("systemd:admin")
+	is.Equal(err.Error(), `wok: systemd socket "admin" not found`)
+	_, err = wok.Listen("systemd:1")
+	is.Equal(err.Error(), `wok: systemd socket "1" not found`)
+}
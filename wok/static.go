@@ -0,0 +1,218 @@
+package wok
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// staticParam is the name of catch-all parameter of static routes
+const staticParam = "filepath"
+
+// CacheRule sets Cache-Control header value for files matching the glob
+// pattern. Pattern is matched by path.Match against file path relative to the
+// file system root and against file name.
+type CacheRule struct {
+	Pattern      string
+	CacheControl string
+}
+
+// StaticOptions configure serving of static files
+type StaticOptions struct {
+	Index         string      // index file of directories, "index.html" if empty
+	Listing       bool        // list contents of directories without index file
+	SPA           bool        // serve root index file for missing paths without extension
+	Precompressed bool        // serve ".br" and ".gz" siblings to clients accepting them
+	Cache         []CacheRule // first matching rule applies
+}
+
+// encodings lists supported precompressed file encodings in order of preference
+var encodings = []struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}}
+
+// Static serves files from the file system, such as embed.FS or os.DirFS,
+// under the prefix. Requests pass through the middleware chain of the router
+// and mws. Responses carry ETag and Last-Modified headers when available, and
+// conditional and range requests are supported. Missing files are reported
+// with ErrNotFound returned to the middleware chain. Static routes are
+// excluded from OpenAPI documents. Conflicts with existing routes are
+// reported as error.
+func (wok *Wok) Static(prefix string, fsys fs.FS, opts StaticOptions, mws ...noodle.Middleware) error {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	s := &static{fsys: fsys, opts: opts}
+	route := &Route{Method: "GET", Handler: "wok.Static", Operation: &Operation{Hidden: true}}
+	chain := wok.prepare(route, UrlJoin(prefix, "*"+staticParam), mws)
+	base := strings.TrimSuffix(route.Path, "/*"+staticParam)
+	handle := wok.convert(writeErrors(chain.Then(s.serve)), route)
+	paths := []string{route.Path}
+	if base != "" {
+		paths = append(paths, base)
+	}
+	return wok.register(route, []string{"GET", "HEAD"}, paths, handle)
+}
+
+type static struct {
+	fsys  fs.FS
+	opts  StaticOptions
+	etags sync.Map // content hashes of files without modification time
+}
+
+func (s *static) serve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	name := strings.TrimPrefix(path.Clean("/"+Var(ctx, staticParam)), "/")
+	if name == "" {
+		name = "."
+	}
+	fi, err := fs.Stat(s.fsys, name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && s.opts.SPA && path.Ext(name) == "":
+		return s.file(w, r, s.opts.Index)
+	case err != nil:
+		return ErrNotFound.Wrap(err)
+	case !fi.IsDir():
+		return s.file(w, r, name)
+	}
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		target := path.Base(r.URL.Path) + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return nil
+	}
+	index := path.Join(name, s.opts.Index)
+	if fi, err := fs.Stat(s.fsys, index); err == nil && !fi.IsDir() {
+		return s.file(w, r, index)
+	}
+	if s.opts.Listing {
+		return s.list(w, name)
+	}
+	return ErrNotFound
+}
+
+// file serves the file or its precompressed sibling
+func (s *static) file(w http.ResponseWriter, r *http.Request, name string) error {
+	for _, rule := range s.opts.Cache {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			w.Header().Set("Cache-Control", rule.CacheControl)
+			break
+		} else if ok, _ := path.Match(rule.Pattern, path.Base(name)); ok {
+			w.Header().Set("Cache-Control", rule.CacheControl)
+			break
+		}
+	}
+	served, encoding := name, ""
+	if s.opts.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+		for _, enc := range encodings {
+			if !accepts(r, enc.name) {
+				continue
+			}
+			if fi, err := fs.Stat(s.fsys, name+enc.ext); err == nil && !fi.IsDir() {
+				served, encoding = name+enc.ext, enc.name
+				break
+			}
+		}
+	}
+	f, err := s.fsys.Open(served)
+	if err != nil {
+		return ErrNotFound.Wrap(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		buf, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(buf)
+	}
+	etag, err := s.etag(served, fi, content)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag)
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	// content type is detected from the name of original file
+	http.ServeContent(w, r, name, fi.ModTime(), content)
+	return nil
+}
+
+// etag derives entity tag from modification time and size of the file, or
+// from its content when modification time is unknown as in embed.FS
+func (s *static) etag(name string, fi fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !fi.ModTime().IsZero() {
+		return fmt.Sprintf(`W/"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()), nil
+	}
+	if res, ok := s.etags.Load(name); ok {
+		return res.(string), nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	res := strconv.Quote(hex.EncodeToString(h.Sum(nil)[:16]))
+	s.etags.Store(name, res)
+	return res, nil
+}
+
+// list writes HTML listing of the directory
+func (s *static) list(w http.ResponseWriter, name string) error {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<pre>")
+	for _, e := range entries {
+		entry := e.Name()
+		if e.IsDir() {
+			entry += "/"
+		}
+		u := url.URL{Path: entry}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(entry))
+	}
+	fmt.Fprintln(w, "</pre>")
+	return nil
+}
+
+// accepts reports whether request accepts content encoding
+func accepts(r *http.Request, encoding string) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(v, ";")
+		if strings.TrimSpace(parts[0]) != encoding {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); strings.HasPrefix(q, "q=") {
+				if f, err := strconv.ParseFloat(q[2:], 64); err == nil && f == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package wok_test
+
+import (
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFS = fstest.MapFS{
+	"index.html":      {Data: []byte("<h1>index</h1>")},
+	"css/app.css":     {Data: []byte("body{}"), ModTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+	"js/app.js":       {Data: []byte("plain")},
+	"js/app.js.gz":    {Data: []byte("gzipped")},
+	"js/app.js.br":    {Data: []byte("brotli")},
+	"docs/index.html": {Data: []byte("docs")},
+	"files/a.txt":     {Data: []byte("a")},
+	"files/<b>/c.txt": {Data: []byte("c")},
+}
+
+func TestStatic(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(tagFactory("A"))
+	wk.Static("/static", testFS, wok.StaticOptions{
+		Precompressed: true,
+		Cache: []wok.CacheRule{
+			{Pattern: "*.js", CacheControl: "max-age=3600"},
+			{Pattern: "css/*", CacheControl: "no-cache"},
+		},
+	}, tagFactory("S"))
+
+	res := noodletest.Get("/static/css/app.css").Serve(t, wk).
+		Status(http.StatusOK).
+		Body("body{}").
+		Header("Content-Type", "text/css; charset=utf-8").
+		Header("Cache-Control", "no-cache").
+		Header("Last-Modified", "Wed, 01 Jan 2020 00:00:00 GMT")
+	is.Equal(res.Recorder.Header()["X-Tag"], []string{"A", "S"})
+	etag := res.Recorder.Header().Get("ETag")
+	is.NotZero(etag)
+	noodletest.Get("/static/css/app.css").Header("If-None-Match", etag).Serve(t, wk).Status(http.StatusNotModified)
+
+	res = noodletest.Get("/static/js/app.js").Header("Accept-Encoding", "gzip, br;q=0").Serve(t, wk).
+		Body("gzipped").
+		Header("Content-Encoding", "gzip").
+		Header("Cache-Control", "max-age=3600").
+		Header("Vary", "Accept-Encoding")
+	is.Equal(res.Recorder.Header().Get("Content-Type")[:22], "text/javascript; chars")
+	noodletest.Get("/static/js/app.js").Header("Accept-Encoding", "gzip, br").Serve(t, wk).Body("brotli")
+	res = noodletest.Get("/static/js/app.js").Serve(t, wk).Body("plain").Header("Content-Encoding", "")
+	etag = res.Recorder.Header().Get("ETag")
+	noodletest.Get("/static/js/app.js").Header("If-None-Match", etag).Serve(t, wk).Status(http.StatusNotModified)
+
+	noodletest.Get("/static").Serve(t, wk).Status(http.StatusMovedPermanently).Header("Location", "/static/")
+	noodletest.Get("/static/").Serve(t, wk).Body("<h1>index</h1>")
+	noodletest.Get("/static/docs/").Serve(t, wk).Body("docs")
+	noodletest.Get("/static/files/").Serve(t, wk).Status(http.StatusNotFound).Body("Not Found\n")
+	noodletest.Get("/static/missing").Serve(t, wk).Status(http.StatusNotFound)
+	noodletest.NewRequest("HEAD", "/static/files/a.txt").Serve(t, wk).Status(http.StatusOK)
+}
+
+func TestStaticSPA(t *testing.T) {
+	wk := wok.New()
+	wk.Static("/", testFS, wok.StaticOptions{SPA: true, Listing: true})
+
+	noodletest.Get("/app/users/12").Serve(t, wk).Status(http.StatusOK).Body("<h1>index</h1>")
+	noodletest.Get("/missing.js").Serve(t, wk).Status(http.StatusNotFound)
+	noodletest.Get("/files/").Serve(t, wk).Body("<pre>\n<a href=\"%3Cb%3E/\">&lt;b&gt;/</a>\n<a href=\"a.txt\">a.txt</a>\n</pre>\n")
+}
@@ -0,0 +1,54 @@
+package wok
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/bind"
+	"github.com/andviro/noodle/render"
+	"net/http"
+)
+
+// StatusCoder is implemented by responses of typed endpoints that set their own HTTP status
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// negotiated renders responses of typed endpoints outside of rendering middleware
+var negotiated = render.ContentType(nil)
+
+// Typed converts function operating on typed request and response values into
+// noodle.Handler. Request body is decoded with binder negotiated by
+// Content-Type and validated if the request implements bind.Validator.
+// Response is rendered by the active rendering middleware or, if there's none,
+// by the renderer negotiated from Accept header. Response status is 200 OK
+// unless response implements StatusCoder.
+func Typed[Req, Resp any](f func(context.Context, Req) (Resp, error)) noodle.Handler {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		var req Req
+		if err := bind.Decode(r, &req); err != nil {
+			return err
+		}
+		target := any(&req) // pointer receivers of Validate are honored
+		if _, ok := any(req).(bind.Validator); ok {
+			target = req
+		}
+		if err := bind.Validate(target); err != nil {
+			return err
+		}
+		resp, err := f(c, req)
+		if err != nil {
+			return err
+		}
+		code := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			code = sc.StatusCode()
+		}
+		yield := func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+			return render.Yield(c, code, resp)
+		}
+		if _, _, ok := render.Active(c); ok {
+			return yield(c, w, r)
+		}
+		return negotiated(yield)(c, w, r)
+	}
+}
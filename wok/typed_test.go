@@ -0,0 +1,96 @@
+package wok_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/andviro/noodle"
+	mw "github.com/andviro/noodle/middleware"
+	"github.com/andviro/noodle/render"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type greetRequest struct {
+	Name string `json:"name" form:"name"`
+}
+
+func (g *greetRequest) Validate() error {
+	if g.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type greetResponse struct {
+	Greeting string `json:"greeting" xml:"greeting"`
+}
+
+type created struct {
+	ID int `json:"id"`
+}
+
+func (created) StatusCode() int {
+	return http.StatusCreated
+}
+
+func greet(ctx context.Context, req greetRequest) (greetResponse, error) {
+	return greetResponse{"Hello " + req.Name}, nil
+}
+
+func typedRequest(wk *wok.Wok, method, path, contentType, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(method, "http://localhost"+path, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	wk.ServeHTTP(w, r)
+	return w
+}
+
+func TestTyped(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New(mw.ErrorHandler)
+	wk.POST("/greet")(wok.Typed(greet))
+	wk.POST("/api/greet", render.XML)(wok.Typed(greet))
+	wk.POST("/items")(wok.Typed(func(ctx context.Context, _ struct{}) (created, error) {
+		return created{1}, nil
+	}))
+
+	w := typedRequest(wk, "POST", "/greet", "application/json", `{"name": "JSON"}`)
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Header().Get("Content-Type"), "application/json;charset=utf-8")
+	var res greetResponse
+	is.NotErr(json.Unmarshal(w.Body.Bytes(), &res))
+	is.Equal(res.Greeting, "Hello JSON")
+
+	w = typedRequest(wk, "POST", "/api/greet", "application/x-www-form-urlencoded", "name=Form")
+	is.Equal(w.Header().Get("Content-Type"), "application/xml;charset=utf-8")
+	is.Equal(w.Body.String(), "<greetResponse><greeting>Hello Form</greeting></greetResponse>")
+
+	w = typedRequest(wk, "POST", "/items", "", "")
+	is.Equal(w.Code, http.StatusCreated)
+}
+
+func TestTypedErrors(t *testing.T) {
+	is := is.New(t)
+	var handled error
+	catch := func(next noodle.Handler) noodle.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			handled = next(ctx, w, r)
+			return handled
+		}
+	}
+	wk := wok.New(catch, mw.ErrorHandler)
+	wk.POST("/greet")(wok.Typed(greet))
+
+	w := typedRequest(wk, "POST", "/greet", "application/json", `{"name": `)
+	is.Equal(w.Code, http.StatusBadRequest)
+	is.Err(handled)
+
+	w = typedRequest(wk, "POST", "/greet", "application/json", `{}`)
+	is.Equal(w.Code, http.StatusUnprocessableEntity)
+	is.Equal(handled.Error(), "Request validation failed: name is required")
+}
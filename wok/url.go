@@ -0,0 +1,70 @@
+package wok
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// URLError is returned by URL when the route path can not be built
+type URLError struct {
+	Route string
+	Param string // empty if route is not found
+}
+
+func (e URLError) Error() string {
+	if e.Param == "" {
+		return "Route `" + e.Route + "` not found"
+	}
+	return "Parameter `" + e.Param + "` is missing for route `" + e.Route + "`"
+}
+
+// URL builds full path of the named route. Route parameters are passed as
+// alternating names and values, values are escaped. All parameters of the
+// route path must be provided.
+func (wok *Wok) URL(name string, params ...string) (string, error) {
+	route, ok := wok.routes.byName(name)
+	if !ok {
+		wok.routes.unresolve(name)
+		return "", URLError{Route: name}
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+	segments := strings.Split(route.Path, "/")
+	for i, s := range segments {
+		if s == "" || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		v, ok := values[s[1:]]
+		if !ok {
+			return "", URLError{Route: name, Param: s[1:]}
+		}
+		if s[0] == ':' {
+			segments[i] = url.PathEscape(v)
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(v, "/"), "/")
+		for j := range parts {
+			parts[j] = url.PathEscape(parts[j])
+		}
+		segments[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// FuncMap returns template functions for URL reversal. Function "url" accepts
+// route name followed by parameter names and values.
+func (wok *Wok) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"url": func(name string, params ...interface{}) (string, error) {
+			strParams := make([]string, len(params))
+			for i, p := range params {
+				strParams[i] = fmt.Sprint(p)
+			}
+			return wok.URL(name, strParams...)
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package wok_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"html/template"
+	"net/http"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/", wok.Name("index"))(handlerFactory("I"))
+	api := wk.Group("/api/")
+	v1 := api.Group("v1", mwFactory("V1"))
+	v1.GET("/items/:id", wok.Name("item"), mwFactory("IT"))(handlerFactory("B"))
+	v1.GET("/files/*path", wok.Name("file"))(handlerFactory("F"))
+
+	u, err := wk.URL("index")
+	is.NotErr(err)
+	is.Equal(u, "/")
+
+	u, err = v1.URL("item", "id", "a b/c")
+	is.NotErr(err)
+	is.Equal(u, "/api/v1/items/a%20b%2Fc")
+
+	u, _ = v1.URL("item", "id", "12")
+	is.Equal(testRequest(wk, "GET", u), "V1>IT>[B]")
+
+	u, err = wk.URL("file", "path", "/dir/my file.txt")
+	is.NotErr(err)
+	is.Equal(u, "/api/v1/files/dir/my%20file.txt")
+
+	_, err = wk.URL("item")
+	is.Equal(err, wok.URLError{Route: "item", Param: "id"})
+	is.Equal(err.Error(), "Parameter `id` is missing for route `item`")
+
+	_, err = wk.URL("missing")
+	is.Equal(err.Error(), "Route `missing` not found")
+}
+
+func TestURLTemplate(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/items/:id", wok.Name("item"))(handlerFactory("B"))
+
+	tpl := template.Must(template.New("link").Funcs(wk.FuncMap()).Parse(`<a href="{{ url "item" "id" .ID }}">`))
+	buf := new(bytes.Buffer)
+	is.NotErr(tpl.Execute(buf, struct{ ID int }{12}))
+	is.Equal(buf.String(), `<a href="/items/12">`)
+
+	tpl = template.Must(template.New("link").Funcs(wk.FuncMap()).Parse(`{{ url "item" }}`))
+	is.Err(tpl.Execute(buf, nil))
+}
+
+func TestDuplicateName(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/a", wok.Name("a"))(handlerFactory("A"))
+	err := wk.GET("/b", wok.Name("a"))(handlerFactory("B"))
+	is.True(errors.Is(err, wok.ErrDuplicateName))
+	is.Equal(err.Error(), "wok: GET /b: name 'a': route name is already taken")
+	noodletest.Get("/b").Serve(t, wk).Status(http.StatusNotFound)
+	is.True(errors.Is(wk.Validate(), wok.ErrDuplicateName))
+	url, err := wk.URL("a")
+	is.NotErr(err)
+	is.Equal(url, "/a")
+}
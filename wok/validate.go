@@ -0,0 +1,138 @@
+package wok
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNoHandler is reported for route closures that were never called
+	ErrNoHandler = errors.New("route has no handler")
+	// ErrUnresolved is reported for route names not found by URL
+	ErrUnresolved = errors.New("route name does not resolve")
+	// ErrDoubleSlash is reported for routes whose path joined with group
+	// prefixes contains double slash
+	ErrDoubleSlash = errors.New("route path contains double slash")
+	// ErrDuplicateName is returned for routes named after existing ones
+	ErrDuplicateName = errors.New("route name is already taken")
+	// ErrFrozen is returned when routes are changed after Build
+	ErrFrozen = errors.New("router is frozen")
+)
+
+// ValidationError lists all problems found by Validate in order of route
+// registration
+type ValidationError struct {
+	Errors []*RouteError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns all problems, so that errors.Is and errors.As look into them
+func (e *ValidationError) Unwrap() []error {
+	res := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		res[i] = err
+	}
+	return res
+}
+
+// Validate reports all problems with route registration at once: route
+// closures never called with handler, duplicate, conflicting and malformed
+// routes, route names that URL failed to resolve and that are still missing,
+// and route paths containing double slashes. Returned error is
+// *ValidationError or nil.
+func (wok *Wok) Validate() error {
+	t := wok.routes
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res []*RouteError
+	for _, r := range t.routes {
+		if strings.Contains(r.Path, "//") {
+			res = append(res, &RouteError{Method: r.Method, Path: r.Path, Err: ErrDoubleSlash})
+		}
+	}
+	for _, r := range t.pending {
+		res = append(res, &RouteError{Method: r.Method, Path: r.Path, Name: r.Name, Err: ErrNoHandler})
+		if r.badPattern != nil {
+			res = append(res, &RouteError{Method: r.Method, Path: r.Path, Err: r.badPattern})
+		}
+	}
+	res = append(res, t.problems...)
+	for _, name := range t.unresolved {
+		if _, ok := t.names[name]; !ok {
+			res = append(res, &RouteError{Name: name, Err: ErrUnresolved})
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: res}
+}
+
+// Build validates routes and freezes the router, so that routes can't be
+// added or removed anymore. It's meant to be called once setup is complete,
+// before serving requests.
+func (wok *Wok) Build() error {
+	err := wok.Validate()
+	t := wok.routes
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.frozen = true
+	return err
+}
+
+// expect registers route waiting for handler
+func (t *routeTable) expect(r *Route) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, r)
+}
+
+// settle removes route from the routes waiting for handler. Must be called
+// with mutex locked.
+func (t *routeTable) settle(r *Route) {
+	for i, p := range t.pending {
+		if p == r {
+			t.pending = append(t.pending[:i:i], t.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// fail records registration error for Validate and returns it. Must be called
+// with mutex locked.
+func (t *routeTable) fail(err error) error {
+	var re *RouteError
+	if errors.As(err, &re) {
+		t.problems = append(t.problems, re)
+	}
+	return err
+}
+
+// unresolve records route name not found by URL before router was frozen
+func (t *routeTable) unresolve(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.frozen {
+		return
+	}
+	for _, n := range t.unresolved {
+		if n == name {
+			return
+		}
+	}
+	t.unresolved = append(t.unresolved, name)
+}
+
+// isFrozen reports whether Build was called
+func (t *routeTable) isFrozen() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.frozen
+}
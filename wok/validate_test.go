@@ -0,0 +1,88 @@
+package wok_test
+
+import (
+	"errors"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	api := wk.Group("/api/")
+	api.GET("/users")(handlerFactory("users"))
+	wk.GET("/users/:id", wok.Name("user"))
+	wk.GET("/posts/:id")(handlerFactory("post"))
+	wk.GET("/posts/:id")(handlerFactory("post"))
+	wk.POST("/posts/:post/comments")(handlerFactory("comment"))
+	wk.GET("/posts/:post/edit")(handlerFactory("edit"))
+	wk.GET("/a*b")(handlerFactory("bad"))
+	_, err := wk.URL("missing")
+	is.Err(err)
+	_, err = wk.URL("late")
+	is.Err(err)
+	wk.GET("/late", wok.Name("late"))(handlerFactory("late"))
+
+	err = wk.Validate()
+	var ve *wok.ValidationError
+	is.True(errors.As(err, &ve))
+	is.Equal(len(ve.Errors), 5)
+	is.Equal(ve.Errors[0].Name, "user")
+	is.True(errors.Is(ve.Errors[1], wok.ErrDuplicate))
+	is.False(errors.Is(err, wok.ErrDoubleSlash))
+	is.True(errors.Is(err, wok.ErrBadPattern))
+	is.True(errors.Is(err, wok.ErrUnresolved))
+	is.True(errors.Is(err, wok.ErrConflict))
+	is.Equal(err.Error(), `wok: GET /users/:id: name 'user': route has no handler
+wok: GET /posts/:id: route is already registered
+wok: GET /posts/:post/edit: route conflicts with existing path /posts/:id
+wok: GET /a*b: malformed route path: parameter must occupy whole segment 'a*b'
+wok: name 'missing': route name does not resolve`)
+	// Validate doesn't freeze
+	is.NotErr(wk.GET("/more")(handlerFactory("more")))
+	// prefix with trailing slash is joined without double slash
+	is.Equal(wk.Routes()[0].Path, "/api/users")
+}
+
+func TestValidateConstraints(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	is.Err(wk.GET("/a/:id<[>")(handlerFactory("a")))
+	wk.GET("/b/:id<(>")
+	err := wk.Validate()
+	var ve *wok.ValidationError
+	is.True(errors.As(err, &ve))
+	is.Equal(len(ve.Errors), 3)
+	is.True(errors.Is(ve.Errors[0], wok.ErrNoHandler))
+	is.Equal(ve.Errors[1].Path, "/b/:id")
+	is.True(errors.Is(ve.Errors[1], wok.ErrBadPattern))
+	is.Equal(ve.Errors[2].Path, "/a/:id")
+	is.True(errors.Is(ve.Errors[2], wok.ErrBadPattern))
+	is.True(errors.Is(wk.Build(), wok.ErrBadPattern))
+}
+
+func TestBuild(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	closure := wk.GET("/users/:id")
+	is.True(errors.Is(wk.Build(), wok.ErrNoHandler))
+
+	err := closure(handlerFactory("user"))
+	is.True(errors.Is(err, wok.ErrFrozen))
+	is.Equal(err.Error(), "wok: GET /users/:id: router is frozen")
+	is.True(errors.Is(wk.Mount("/mnt", http.NotFoundHandler()), wok.ErrFrozen))
+	is.False(wk.Remove("GET", "/users/:id"))
+	is.True(errors.Is(wk.Batch(func(*wok.Tx) {}), wok.ErrFrozen))
+	noodletest.Get("/users/1").Serve(t, wk).Status(404)
+
+	wk = wok.New()
+	wk.GET("/users/:id", wok.Name("user"))(handlerFactory("user"))
+	is.NotErr(wk.Build())
+	_, err = wk.URL("missing")
+	is.Err(err)
+	is.NotErr(wk.Validate())
+	noodletest.Get("/users/1").Serve(t, wk).Body("[user]")
+}
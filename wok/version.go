@@ -0,0 +1,184 @@
+package wok
+
+import (
+	"context"
+	"github.com/andviro/noodle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Versioning configures how API version is selected besides the path
+type Versioning struct {
+	Header    string // request header carrying version such as "2" or "v2"
+	MediaType string // vendor media type, e.g. "application/vnd.app" selects version 2 for "application/vnd.app.v2+json" in Accept header
+}
+
+// Versions dispatches requests under the prefix to routes of API versions
+type Versions struct {
+	cfg      Versioning
+	group    *Wok
+	versions []*apiVersion // oldest first
+}
+
+type apiVersion struct {
+	name       string
+	deprecated time.Time // zero if version is not deprecated
+	sunset     time.Time
+	wok        *Wok
+}
+
+// Versions creates versioned group under the prefix. Version is selected
+// by the path segment following the prefix, e.g. "/api/v2/users", or by
+// header and media type set in cfg for unversioned paths like "/api/users".
+// Requests are served by the route of the newest version that is not newer
+// than the selected one and has the route for the request path and method.
+// Without explicit version the newest version is used. Versions own all
+// paths under the prefix. Middlewares apply to all versions. Conflict of the
+// prefix with existing routes is reported by Validate.
+func (wok *Wok) Versions(prefix string, cfg Versioning, mws ...noodle.Middleware) *Versions {
+	res := &Versions{cfg: cfg, group: wok.Group(prefix, mws...)}
+	base := res.group.fullPrefix()
+	handle := func(w http.ResponseWriter, r *http.Request, _ Params) {
+		res.serve(w, r)
+	}
+	// errors are recorded for Validate
+	_ = wok.register(nil, MountMethods, []string{base, UrlJoin(base, "*"+mountParam)}, handle)
+	return res
+}
+
+// Version adds API version with its own routes. Versions must be added from
+// the oldest to the newest. Routes of the version are registered under
+// "v<name>" path segment.
+func (vs *Versions) Version(name string, mws ...noodle.Middleware) *Wok {
+	g := vs.group
+	res := &Wok{
+		prefix:  "v" + name,
+		version: name,
+		parent:  g,
+		Router:  NewRouter(),
+		chain:   noodle.New(mws...),
+		routes:  g.routes,
+	}
+	res.Router.NotFound = res.fallback(notFound)
+	res.Router.MethodNotAllowed = res.fallback(methodNotAllowed)
+	res.Router.GlobalOPTIONS = res.fallback(options)
+	g.routes.addGroup(res)
+	vs.versions = append(vs.versions, &apiVersion{name: name, wok: res})
+	return res
+}
+
+// Deprecate marks version as deprecated since the time, which may be in the
+// future. Responses of the version get Deprecation header as defined by RFC
+// 9745, and Sunset header if sunset time is not zero.
+func (vs *Versions) Deprecate(name string, since, sunset time.Time) {
+	for _, v := range vs.versions {
+		if v.name == name {
+			v.deprecated, v.sunset = since, sunset
+		}
+	}
+}
+
+// Version returns API version that serves the request, or empty string
+// outside of versioned groups
+func Version(c context.Context) string {
+	res, _ := c.Value(versionKey).(string)
+	return res
+}
+
+// find returns index of the version by its name, or -1
+func (vs *Versions) find(name string) int {
+	name = strings.TrimPrefix(strings.ToLower(name), "v")
+	for i, v := range vs.versions {
+		if strings.ToLower(v.name) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// requested returns index of the version selected by the request, or -1,
+// and reports whether version was selected by path segment
+func (vs *Versions) requested(r *http.Request, segment string) (int, bool) {
+	if i := vs.find(segment); i >= 0 && strings.HasPrefix(segment, "v") {
+		return i, true
+	}
+	if vs.cfg.Header != "" {
+		if i := vs.find(r.Header.Get(vs.cfg.Header)); i >= 0 {
+			return i, false
+		}
+	}
+	if vs.cfg.MediaType != "" {
+		prefix := strings.ToLower(vs.cfg.MediaType) + "."
+		for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+			mt := strings.ToLower(strings.TrimSpace(strings.SplitN(accept, ";", 2)[0]))
+			if !strings.HasPrefix(mt, prefix) {
+				continue
+			}
+			if i := vs.find(strings.SplitN(mt[len(prefix):], "+", 2)[0]); i >= 0 {
+				return i, false
+			}
+		}
+	}
+	return -1, false
+}
+
+// serve selects version for the request and passes it to the version router
+// with the path rewritten to contain the version segment
+func (vs *Versions) serve(w http.ResponseWriter, r *http.Request) {
+	if vs.cfg.Header != "" {
+		w.Header().Add("Vary", vs.cfg.Header)
+	}
+	if vs.cfg.MediaType != "" {
+		w.Header().Add("Vary", "Accept")
+	}
+	if len(vs.versions) == 0 {
+		vs.group.routerRoot().Router.NotFound.ServeHTTP(w, r)
+		return
+	}
+	base := strings.TrimSuffix(vs.group.fullPrefix(), "/")
+	skip := strings.Count(base, "/")
+	rest := skipSegments(r.URL.Path, skip)
+	selected, explicit := vs.requested(r, strings.SplitN(rest, "/", 2)[0])
+	if explicit {
+		skip++
+	}
+	if selected < 0 {
+		selected = len(vs.versions) - 1
+	}
+	rest = "/" + skipSegments(r.URL.Path, skip)
+	v := vs.versions[selected]
+	for i := selected; i >= 0; i-- {
+		if h, _, _ := vs.versions[i].wok.live().Lookup(r.Method, vs.versions[i].path(base, rest)); h != nil {
+			v = vs.versions[i]
+			break
+		}
+	}
+	if !v.deprecated.IsZero() {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(v.deprecated.Unix(), 10))
+		if !v.sunset.IsZero() {
+			w.Header().Set("Sunset", v.sunset.UTC().Format(http.TimeFormat))
+		}
+	}
+	ctx := context.WithValue(r.Context(), versionKey, v.name)
+	if OriginalPath(ctx) == "" {
+		ctx = context.WithValue(ctx, originalPathKey, r.URL.Path)
+	}
+	req := r.WithContext(ctx)
+	u := *r.URL
+	u.Path = v.path(base, rest)
+	if r.URL.RawPath != "" {
+		u.RawPath = v.path(base, "/"+skipSegments(r.URL.RawPath, skip))
+	}
+	req.URL = &u
+	v.wok.preRoute(w, req, v.wok.live().ServeHTTP)
+}
+
+// path returns path of the version route
+func (v *apiVersion) path(base, rest string) string {
+	if rest == "/" {
+		return base + "/v" + v.name
+	}
+	return base + "/v" + v.name + rest
+}
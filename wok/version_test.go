@@ -0,0 +1,62 @@
+package wok_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andviro/noodle/noodletest"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func versionHandler(tag string) func(context.Context, http.ResponseWriter, *http.Request) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprintf(w, "[%s %s %s %s]", tag, wok.Version(ctx), wok.Var(ctx, "id"), wok.OriginalPath(ctx))
+		return nil
+	}
+}
+
+func TestVersions(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/")(handlerFactory("root"))
+	vs := wk.Versions("/api", wok.Versioning{Header: "X-API-Version", MediaType: "application/vnd.app"}, mwFactory("API"))
+	v1 := vs.Version("1", mwFactory("V1"))
+	v1.GET("/users/:id", wok.Name("v1.user"))(versionHandler("user"))
+	v1.GET("/items")(versionHandler("items"))
+	v2 := vs.Version("2", mwFactory("V2"))
+	v2.GET("/users/:id")(versionHandler("user"))
+	vs.Version("3").GET("/")(versionHandler("index"))
+	vs.Deprecate("1", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noodletest.Get("/").Serve(t, wk).Body("[root]")
+	res := noodletest.Get("/api/v1/users/12").Serve(t, wk).Body("API>V1>[user 1 12 /api/v1/users/12]")
+	is.Equal(res.Recorder.Header().Get("Deprecation"), "@1735689600")
+	is.Equal(res.Recorder.Header().Get("Sunset"), "Tue, 01 Jan 2030 00:00:00 GMT")
+	is.Equal(res.Recorder.Header()["Vary"], []string{"X-API-Version", "Accept"})
+
+	res = noodletest.Get("/api/users/12").Serve(t, wk).Body("API>V2>[user 2 12 /api/users/12]")
+	is.Equal(res.Recorder.Header().Get("Deprecation"), "")
+	noodletest.Get("/api/users/12").Header("X-API-Version", "v1").Serve(t, wk).Body("API>V1>[user 1 12 /api/users/12]")
+	noodletest.Get("/api/users/12").Header("Accept", "text/html, application/vnd.app.v1+json;q=0.9").Serve(t, wk).
+		Body("API>V1>[user 1 12 /api/users/12]")
+	noodletest.Get("/api/v2/items").Serve(t, wk).Body("API>V1>[items 1  /api/v2/items]")
+	noodletest.Get("/api").Serve(t, wk).Body("API>[index 3  /api]")
+	noodletest.Get("/api/v2").Serve(t, wk).Body("API>V2>")
+
+	url, err := wk.URL("v1.user", "id", "1")
+	is.NotErr(err)
+	is.Equal(url, "/api/v1/users/1")
+}
+
+func TestVersionsConflict(t *testing.T) {
+	is := is.New(t)
+	wk := wok.New()
+	wk.GET("/api")(handlerFactory("api"))
+	wk.Versions("/api", wok.Versioning{}).Version("1").GET("/users")(handlerFactory("users"))
+	noodletest.Get("/api").Serve(t, wk).Body("[api]")
+	is.True(errors.Is(wk.Validate(), wok.ErrDuplicate))
+}
@@ -1,90 +1,160 @@
 package wok
 
 import (
+	"context"
 	"github.com/andviro/noodle"
-	"github.com/julienschmidt/httprouter"
-	"golang.org/x/net/context"
 	"net/http"
+	"sync/atomic"
 )
 
-// Wok is a simple wrapper for httprouter with route groups and native support for noodle.Handler
+// Wok is a router with route groups and native support for noodle.Handler
 type Wok struct {
-	prefix  string
-	parent  *Wok
-	chain   noodle.Chain
-	rootCtx context.Context
-	*httprouter.Router
+	prefix    string
+	host      string // host pattern of host groups
+	resource  string // name of the resource for member groups
+	version   string // name of API version for version groups
+	parent    *Wok
+	chain     noodle.Chain
+	pre       noodle.Chain // pre-routing middlewares of the group owning router
+	rootCtx   atomic.Pointer[context.Context]
+	tracer    noodle.Tracer
+	routes    *routeTable
+	fallbacks [3]noodle.Handler
+	// fallbackCache holds fallback handlers wrapped in the group chain
+	fallbackCache [3]atomic.Pointer[cachedFallback]
+	*Router
 }
 
-type RouteClosure func(noodle.Handler)
+// RouteClosure registers handler for the route. Routes conflicting with
+// existing ones are reported as error and not registered.
+type RouteClosure func(noodle.Handler) error
 
 type key int
 
 const (
 	paramKey key = iota
+	allowKey
+	originalPathKey
+	hostKey
+	versionKey
+	routeKey
 )
 
-var todoCtx = context.TODO()
-
-// convert turns noodle.Handler into httprouter.Handle
-func (wok *Wok) convert(h noodle.Handler) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		_ = h(context.WithValue(wok.context(), paramKey, p), w, r)
+// convert turns noodle.Handler of the route into Handle. Handler
+// context is derived from the request context with the root context layered
+// on top. Requests with parameters not satisfying route constraints are passed
+// to NotFound handler. Parameters are copied, since the context may outlive
+// the request.
+func (wok *Wok) convert(h noodle.Handler, route *Route) Handle {
+	return func(w http.ResponseWriter, r *http.Request, p Params) {
+		if !check(route.constraints, p) {
+			wok.Router.NotFound.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := layer(r.Context(), wok.context())
+		defer cancel()
+		if p != nil {
+			p = append(make(Params, 0, len(p)), p...)
+		}
+		_ = h(context.WithValue(context.WithValue(ctx, routeKey, route), paramKey, p), w, r)
 	}
 }
 
 // New creates new Wok initialized with middlewares.
 // The resulting middleware chain will be called for all routes in Wok
 func New(mws ...noodle.Middleware) *Wok {
-	return &Wok{
-		Router: httprouter.New(),
+	res := &Wok{
+		Router: NewRouter(),
 		chain:  noodle.New(mws...),
+		routes: newRouteTable(),
 	}
+	res.Router.NotFound = res.fallback(notFound)
+	res.Router.MethodNotAllowed = res.fallback(methodNotAllowed)
+	res.Router.GlobalOPTIONS = res.fallback(options)
+	res.routes.addGroup(res)
+	return res
 }
 
-// context determines context for the handler.
+// context determines root context for the handler, returns nil if none was set.
 func (wok *Wok) context() context.Context {
-	if wok.rootCtx != nil {
-		return wok.rootCtx
+	if ctx := wok.rootCtx.Load(); ctx != nil && *ctx != nil {
+		return *ctx
 	}
 	if wok.parent != nil {
 		return wok.parent.context()
 	}
-	return todoCtx
+	return nil
 }
 
-// SetRootCtx injects user-supplied context into the router.
+// SetContext injects user-supplied root context into the router. Values from
+// the root context are visible when missing from request context, and cancelling
+// the root context cancels all requests handled by the router.
 // Note that you can set the context for subrouters.
 // If subrouter context is not set explicitly, it will be inherited from its parent.
 func (wok *Wok) SetContext(ctx context.Context) {
-	wok.rootCtx = ctx
+	wok.rootCtx.Store(&ctx)
 }
 
-// Handle allows to attach some noodle Middlewares and a Handle to a route
+// Handle allows to attach some noodle Middlewares and a Handle to a route.
+// Route options such as Name may be passed among middlewares.
 func (wok *Wok) Handle(method, path string, mws ...noodle.Middleware) RouteClosure {
-	chain := noodle.New(mws...)
+	route := &Route{Method: method}
+	chain := wok.prepare(route, path, mws)
+	wok.routes.expect(route)
+	return func(h noodle.Handler) error {
+		route.Handler = funcName(h)
+		h = chain.Then(h)
+		return wok.register(route, []string{method}, []string{route.Path}, wok.convert(h, route))
+	}
+}
+
+// prepare applies route options, fills route path, groups and middleware
+// descriptions and returns full middleware chain for the route
+func (wok *Wok) prepare(route *Route, path string, mws []noodle.Middleware) noodle.Chain {
+	chain := noodle.New(applyOptions(route, mws)...)
+	var tracer noodle.Tracer
 	for router := wok; router != nil; router = router.parent {
 		chain = router.chain.Use(chain...)
 		path = UrlJoin(router.prefix, path)
+		if tracer == nil {
+			tracer = router.tracer
+		}
+		if router.parent != nil && router.host == "" {
+			route.Groups = append([]string{router.prefix}, route.Groups...)
+		}
 	}
-	return func(h noodle.Handler) {
-		h = chain.Then(h)
-		wok.Router.Handle(method, path, wok.convert(h))
+	route.Host = wok.activeHost()
+	route.Middlewares = chain.Describe()
+	if tracer != nil {
+		chain = chain.Trace(tracer)
 	}
+	route.Path, route.Constraints, route.constraints, route.badPattern = parseConstraints(path)
+	return chain
+}
+
+// Trace makes middlewares of routes registered afterwards report entry, exit
+// and elapsed time to the tracer. Setting on a group overrides parent's tracer.
+func (wok *Wok) Trace(t noodle.Tracer) {
+	wok.tracer = t
+	wok.routes.fallbackGen.Add(1)
 }
 
 // Group starts new route group with common prefix.
 // Middleware passed to Group will be used for all routes in it.
 func (wok *Wok) Group(prefix string, mws ...noodle.Middleware) *Wok {
-	return &Wok{
+	res := &Wok{
 		prefix: prefix,
 		parent: wok,
 		Router: wok.Router,
 		chain:  noodle.New(mws...),
+		routes: wok.routes,
 	}
+	wok.routes.addGroup(res)
+	return res
 }
 
 // Var returns route variable for context or empty string
 func Var(c context.Context, name string) string {
-	return c.Value(paramKey).(httprouter.Params).ByName(name)
+	res, _ := lookup(c, name)
+	return res
 }
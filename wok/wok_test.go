@@ -1,13 +1,14 @@
 package wok_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/andviro/noodle"
 	mw "github.com/andviro/noodle/middleware"
 	"github.com/andviro/noodle/render"
 	"github.com/andviro/noodle/wok"
-	"golang.org/x/net/context"
 	"gopkg.in/tylerb/is.v1"
 	"io/ioutil"
 	"net/http"
@@ -85,6 +86,68 @@ func TestSetContext(t *testing.T) {
 	is.Equal(testRequest(wk, "GET", "/g2"), "A>G2>G21>[*C*]")
 }
 
+func TestRequestCancellation(t *testing.T) {
+	is := is.New(t)
+	root, cancelRoot := context.WithCancel(context.WithValue(context.TODO(), "B", "*B*"))
+	wk := wok.New()
+	wk.SetContext(root)
+	var errs []error
+	wk.GET("/")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		is.Equal(ctx.Value("B"), "*B*")
+		is.Equal(ctx.Value("R"), "*R*")
+		errs = append(errs, ctx.Err())
+		return nil
+	})
+
+	req, cancelReq := context.WithCancel(context.WithValue(context.TODO(), "R", "*R*"))
+	r, _ := http.NewRequest("GET", "http://localhost/", nil)
+	wk.ServeHTTP(httptest.NewRecorder(), r.WithContext(req))
+	cancelReq()
+	wk.ServeHTTP(httptest.NewRecorder(), r.WithContext(req))
+	cancelRoot()
+	wk.ServeHTTP(httptest.NewRecorder(), r.WithContext(context.WithValue(context.TODO(), "R", "*R*")))
+	is.Equal(errs, []error{nil, context.Canceled, context.Canceled})
+}
+
+func TestRequestCancelCause(t *testing.T) {
+	is := is.New(t)
+	root, cancelRoot := context.WithCancelCause(context.WithValue(context.TODO(), "B", "*B*"))
+	wk := wok.New()
+	wk.SetContext(root)
+	var causes []error
+	wk.GET("/")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		is.Equal(ctx.Value("B"), "*B*")
+		causes = append(causes, context.Cause(ctx))
+		return nil
+	})
+
+	clientGone := errors.New("client gone")
+	req, cancelReq := context.WithCancelCause(context.TODO())
+	cancelReq(clientGone)
+	r, _ := http.NewRequest("GET", "http://localhost/", nil)
+	wk.ServeHTTP(httptest.NewRecorder(), r.WithContext(req))
+	shutdown := errors.New("shutdown")
+	cancelRoot(shutdown)
+	wk.ServeHTTP(httptest.NewRecorder(), r.WithContext(context.TODO()))
+	is.Equal(causes, []error{clientGone, shutdown})
+}
+
+func TestTrace(t *testing.T) {
+	is := is.New(t)
+	var names []string
+	wk := wok.New(noodle.Named("A", mwFactory("A")))
+	wk.Trace(func(ctx context.Context, e noodle.TraceEvent) {
+		if !e.Exit {
+			names = append(names, e.Info.Name)
+		}
+	})
+	g := wk.Group("/g", noodle.Named("G", mwFactory("G")))
+	g.GET("/")(handlerFactory("B"))
+
+	is.Equal(testRequest(wk, "GET", "/g"), "A>G>[B]")
+	is.Equal(names, []string{"A", "G"})
+}
+
 func TestRouterVars(t *testing.T) {
 	is := is.New(t)
 	mw := func(next noodle.Handler) noodle.Handler {
@@ -101,46 +164,7 @@ func TestRouterVars(t *testing.T) {
 	is.Equal(testRequest(wk, "GET", "/1/2"), "MW>[1][2][testValue]")
 }
 
-func ExampleApplication() {
-	// globalErrorHandler receives all errors from all handlers
-	// and tries to return meaningful HTTP status and message
-	globalErrorHandler := func(next noodle.Handler) noodle.Handler {
-		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-			err := next(ctx, w, r)
-			switch err {
-			case mw.UnauthorizedRequest:
-				w.WriteHeader(401)
-				fmt.Fprint(w, "Please provide credentials")
-			case nil:
-				return nil
-			default:
-				w.WriteHeader(500)
-				fmt.Fprintf(w, "There was an error: %v", err)
-			}
-			return err
-		}
-	}
-
-	// apiErrorHandler is a specific error catcher that renders its messages into JSON
-	apiErrorHandler := func(next noodle.Handler) noodle.Handler {
-		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-			err := next(ctx, w, r)
-			switch err {
-			case mw.UnauthorizedRequest:
-				render.Yield(ctx, 401, map[string]interface{}{
-					"error": err,
-				})
-			case nil:
-				return nil
-			default:
-				render.Yield(ctx, 500, map[string]interface{}{
-					"error": err,
-				})
-			}
-			return err
-		}
-	}
-
+func Example_application() {
 	// apiAuth guards access to api group
 	apiAuth := mw.HTTPAuth("API", func(user, pass string) bool {
 		return pass == "Secret"
@@ -150,8 +174,9 @@ func ExampleApplication() {
 		return pass == "Password"
 	})
 
-	// w is the root router
-	w := wok.Default(globalErrorHandler)
+	// w is the root router, ErrorHandler converts errors from all handlers
+	// into meaningful HTTP status and message
+	w := wok.Default(mw.ErrorHandler)
 
 	// Handle index page
 	w.GET("/")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -159,8 +184,9 @@ func ExampleApplication() {
 		return nil
 	})
 
-	// api is a group of routes with common authentication, result rendering and error handling
-	api := w.Group("/api", render.JSON, apiErrorHandler, apiAuth)
+	// api is a group of routes with common authentication and result rendering.
+	// Errors in this group are rendered into JSON
+	api := w.Group("/api", render.JSON, apiAuth)
 	{
 		api.GET("/")(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			res := []int{1, 2, 3, 4, 5}
@@ -213,8 +239,15 @@ func ExampleApplication() {
 	json.NewDecoder(resp.Body).Decode(&obj)
 	fmt.Println(obj)
 
+	// api without credentials
+	resp, _ = http.Get("http://localhost:8989/api/12")
+	var apiErr map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&apiErr)
+	fmt.Println(resp.StatusCode, apiErr)
+
 	// Output: Index page
 	// Hello user
 	// [1 2 3 4 5]
 	// map[ID:12]
+	// 401 map[code:unauthorized message:Unauthorized request]
 }
@@ -0,0 +1,19 @@
+package wok
+
+import (
+	"github.com/andviro/noodle"
+	"github.com/andviro/noodle/websocket"
+)
+
+// WS registers WebSocket route. Upgrade request passes through the middleware
+// chain like any other GET request, and the upgrader may be configured with
+// websocket.With middleware.
+func (wok *Wok) WS(path string, mws ...noodle.Middleware) func(websocket.Handler) error {
+	route := &Route{Method: "GET"}
+	chain := wok.prepare(route, path, mws)
+	wok.routes.expect(route)
+	return func(h websocket.Handler) error {
+		route.Handler = funcName(h)
+		return wok.register(route, []string{"GET"}, []string{route.Path}, wok.convert(chain.Then(websocket.Handle(h)), route))
+	}
+}
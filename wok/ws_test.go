@@ -0,0 +1,82 @@
+package wok_test
+
+import (
+	"bytes"
+	"context"
+	mw "github.com/andviro/noodle/middleware"
+	"github.com/andviro/noodle/websocket"
+	"github.com/andviro/noodle/wok"
+	"gopkg.in/tylerb/is.v1"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func welcome(ctx context.Context, c *websocket.Conn) error {
+	return c.WriteMessage(websocket.TextMessage, []byte("hello "+mw.GetUser(ctx)+" "+wok.Var(ctx, "room")))
+}
+
+// syncBuffer collects log output written from server goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) waitFor(s string) bool {
+	for i := 0; i < 100; i++ {
+		b.mu.Lock()
+		found := strings.Contains(b.buf.String(), s)
+		b.mu.Unlock()
+		if found {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestWS(t *testing.T) {
+	is := is.New(t)
+	buf := new(syncBuffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+	wk := wok.New(mw.Logger, mw.ErrorHandler)
+	wk.WS("/rooms/:room", mw.HTTPAuth("test", func(u, p string) bool { return p == "secret" }),
+		websocket.With(websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}))(welcome)
+	srv := httptest.NewServer(wk)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/rooms/lobby"
+
+	_, resp, err := websocket.Dial(context.Background(), url, nil)
+	is.Equal(err, websocket.ErrBadHandshake)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	r, _ := http.NewRequest("GET", url, nil)
+	r.SetBasicAuth("user", "secret")
+	c, _, err := websocket.Dial(context.Background(), url, http.Header{
+		"Authorization": r.Header["Authorization"],
+		"Origin":        {"http://other.com"},
+	})
+	is.NotErr(err)
+	_, msg, err := c.ReadMessage()
+	is.NotErr(err)
+	is.Equal(string(msg), "hello user lobby")
+	_, _, err = c.ReadMessage()
+	is.Err(err)
+	is.True(buf.waitFor("GET /rooms/lobby (101)"))
+
+	route := wk.Routes()[0]
+	is.Equal(route.Handler, "wok_test.welcome")
+	is.Equal(route.Middlewares[len(route.Middlewares)-1].Name, "websocket.With")
+}